@@ -0,0 +1,128 @@
+// Command migrate applies and rolls back the SQL migrations in migrations/
+//
+//	migrate [-config config.yaml] up            Apply all pending migrations
+//	migrate [-config config.yaml] down [n]      Roll back the last n migrations, default 1
+//	migrate [-config config.yaml] to <version>  Migrate up or down to version, 0 rolls back all
+//	migrate [-config config.yaml] status        List migrations and when they were applied
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/migrations"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/migrate"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to the configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config file] up | down [n] | to <version> | status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database instance: %v", err)
+	}
+	defer sqlDB.Close()
+
+	driver := db.Dialector.Name()
+	fsys, err := migrations.For(driver)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator, err := migrate.New(sqlDB, driver, fsys)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	var done []migrate.Migration
+	switch flag.Arg(0) {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of migrations: %s", flag.Arg(1))
+			}
+		}
+		done, err = migrator.Down(ctx, steps)
+	case "to":
+		if flag.NArg() < 2 {
+			log.Fatal("Missing version")
+		}
+		version, parseErr := strconv.ParseUint(flag.Arg(1), 10, 64)
+		if parseErr != nil {
+			log.Fatalf("Invalid version: %s", flag.Arg(1))
+		}
+		done, err = migrator.To(ctx, version)
+	case "status":
+		if err := printStatus(ctx, migrator); err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, m := range done {
+		fmt.Printf("%s %d_%s\n", flag.Arg(0), m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if len(done) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+}
+
+// printStatus prints a table of all migrations
+func printStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		note := ""
+		switch {
+		case s.Missing:
+			note = "file missing"
+		case s.Modified:
+			note = "modified after it was applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, appliedAt, note)
+	}
+	return w.Flush()
+}
@@ -14,12 +14,15 @@ import (
 	"github.com/IndigoCloud6/go-web-template/internal/middleware"
 	"github.com/IndigoCloud6/go-web-template/internal/model"
 	"github.com/IndigoCloud6/go-web-template/internal/wire"
-	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/migrations"
 	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/migrate"
 	"github.com/IndigoCloud6/go-web-template/pkg/response"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	_ "github.com/IndigoCloud6/go-web-template/docs"
 )
@@ -43,6 +46,11 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key created under /api/v1/api-keys.
 func main() {
 	// Load configuration
 	cfg, err := config.Load("config.yaml")
@@ -58,30 +66,43 @@ func main() {
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
-	// Initialize database and auto-migrate
-	db, err := database.NewMySQL(&cfg.Database)
+	// Load the token signing keys up front so a bad key configuration fails at startup
+	if _, err := middleware.Keys(&cfg.JWT); err != nil {
+		logger.Fatal("Failed to load JWT keys", zap.Error(err))
+	}
+
+	// Initialize app with Wire, which also connects to the database
+	app, err := wire.InitializeApp(cfg)
 	if err != nil {
-		logger.Fatal("Failed to connect to database")
+		logger.Fatal("Failed to initialize app", zap.Error(err))
 	}
+	handlers := app.Handlers
 
-	// Auto-migrate models
-	if err := db.AutoMigrate(&model.User{}, &model.Product{}); err != nil {
-		logger.Fatal("Failed to auto-migrate database")
+	// Apply pending migrations; other replicas starting at the same time wait
+	// for the lock and then find nothing left to apply
+	if cfg.Database.MigrateOnStart {
+		if err := migrateDatabase(app.DB); err != nil {
+			logger.Fatal("Failed to migrate database", zap.Error(err))
+		}
 	}
 
-	// Initialize app with Wire
-	handlers, err := wire.InitializeApp(cfg)
-	if err != nil {
-		logger.Fatal("Failed to initialize app")
+	// Seed built-in roles and promote the bootstrap admin if there is none yet
+	if err := app.RoleService.SeedDefaults(context.Background()); err != nil {
+		logger.Fatal("Failed to seed roles", zap.Error(err))
+	}
+	if err := app.RoleService.BootstrapAdmin(context.Background()); err != nil {
+		logger.Fatal("Failed to bootstrap admin", zap.Error(err))
 	}
 
 	// Create Gin router
 	r := gin.New()
 
 	// Apply middleware
+	r.Use(middleware.RequestID())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.Logger())
-	r.Use(middleware.CORS())
+	r.Use(middleware.CORS(&cfg.CORS))
+	r.Use(middleware.ReadYourWrites(&cfg.Database))
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -90,42 +111,122 @@ func main() {
 		})
 	})
 
+	// Public keys for verifying access tokens
+	r.GET("/.well-known/jwks.json", handlers.AuthHandler.JWKS)
+
+	// OAuth2 token endpoint for service-to-service calls
+	r.POST("/oauth/token", handlers.OAuthClientHandler.Token)
+
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API routes
 	v1 := r.Group("/api/v1")
+	// Browser clients on cookie sessions must send the CSRF token with unsafe requests
+	v1.Use(middleware.CSRF(&cfg.JWT))
 	{
+		// Middleware for routes that require an authenticated user
+		authRequired := middleware.JWTAuth(&cfg.JWT, app.TokenService)
+		// Middleware for resource routes that machine clients may call with an API key
+		apiAuth := middleware.JWTOrAPIKeyAuth(&cfg.JWT, app.APIKeyService, app.TokenService)
+
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", handlers.AuthHandler.Login)
+			auth.POST("/refresh", handlers.AuthHandler.RefreshToken)
+			auth.POST("/password/forgot", handlers.AuthHandler.ForgotPassword)
+			auth.POST("/password/reset", handlers.AuthHandler.ResetPassword)
+			auth.POST("/email/verify", handlers.AuthHandler.VerifyEmail)
+			auth.POST("/email/resend", handlers.AuthHandler.ResendVerification)
+			auth.POST("/mfa/verify", handlers.AuthHandler.VerifyMFA)
+			if cfg.OIDC.Enabled {
+				auth.GET("/oidc/login", handlers.AuthHandler.OIDCLogin)
+				auth.GET("/oidc/callback", handlers.AuthHandler.OIDCCallback)
+			}
 		}
 
-		// Protected auth routes
+		// Protected auth routes. Changes to the account's credentials are reserved
+		// to its owner and rejected for admins impersonating the user.
 		authProtected := v1.Group("/auth")
-		authProtected.Use(middleware.JWTAuth(&cfg.JWT))
+		authProtected.Use(authRequired)
 		{
-			authProtected.POST("/refresh", handlers.AuthHandler.RefreshToken)
+			ownerOnly := middleware.DenyImpersonation()
+
+			authProtected.POST("/logout", handlers.AuthHandler.Logout)
+			authProtected.POST("/logout-all", ownerOnly, handlers.AuthHandler.LogoutAll)
 			authProtected.GET("/me", handlers.AuthHandler.GetCurrentUser)
+			authProtected.PATCH("/me", ownerOnly, handlers.AuthHandler.UpdateCurrentUser)
+			authProtected.DELETE("/me", ownerOnly, handlers.AuthHandler.DeleteCurrentUser)
+			authProtected.POST("/me/password", ownerOnly, handlers.AuthHandler.ChangePassword)
+			authProtected.GET("/sessions", handlers.AuthHandler.ListSessions)
+			authProtected.DELETE("/sessions/:id", ownerOnly, handlers.AuthHandler.RevokeSession)
+			authProtected.POST("/mfa/enroll", ownerOnly, handlers.MFAHandler.Enroll)
+			authProtected.POST("/mfa/enroll/confirm", ownerOnly, handlers.MFAHandler.ConfirmEnrollment)
+			authProtected.POST("/mfa/disable", ownerOnly, handlers.MFAHandler.Disable)
+			authProtected.POST("/mfa/recovery-codes", ownerOnly, handlers.MFAHandler.RegenerateRecoveryCodes)
 		}
 
+		// User registration is public, reads require permissions and changes to
+		// other accounts are reserved to admins; users manage their own through /auth/me
 		users := v1.Group("/users")
 		{
+			usersRead := middleware.RequirePermission(model.PermissionUsersRead)
+			usersWrite := middleware.RequirePermission(model.PermissionUsersWrite)
+			adminOnly := middleware.RequireRole(model.RoleAdmin)
+
 			users.POST("", handlers.UserHandler.CreateUser)
-			users.GET("", handlers.UserHandler.ListUsers)
-			users.GET("/:id", handlers.UserHandler.GetUser)
-			users.PUT("/:id", handlers.UserHandler.UpdateUser)
-			users.DELETE("/:id", handlers.UserHandler.DeleteUser)
+			users.GET("", apiAuth, usersRead, handlers.UserHandler.ListUsers)
+			users.GET("/:id", apiAuth, usersRead, handlers.UserHandler.GetUser)
+			users.PUT("/:id", apiAuth, adminOnly, usersWrite, handlers.UserHandler.UpdateUser)
+			users.DELETE("/:id", apiAuth, adminOnly, usersWrite, handlers.UserHandler.DeleteUser)
 		}
 
+		// Products are publicly readable, writes require permissions
 		products := v1.Group("/products")
 		{
-			products.POST("", handlers.ProductHandler.CreateProduct)
+			productsWrite := middleware.RequirePermission(model.PermissionProductsWrite)
+
+			products.POST("", apiAuth, productsWrite, handlers.ProductHandler.CreateProduct)
 			products.GET("", handlers.ProductHandler.ListProducts)
 			products.GET("/:id", handlers.ProductHandler.GetProduct)
-			products.PUT("/:id", handlers.ProductHandler.UpdateProduct)
-			products.DELETE("/:id", handlers.ProductHandler.DeleteProduct)
+			products.PUT("/:id", apiAuth, productsWrite, handlers.ProductHandler.UpdateProduct)
+			products.DELETE("/:id", apiAuth, productsWrite, handlers.ProductHandler.DeleteProduct)
+		}
+
+		// API key management always requires a user session, and the user's own
+		apiKeys := v1.Group("/api-keys")
+		apiKeys.Use(authRequired, middleware.DenyImpersonation())
+		{
+			apiKeys.POST("", handlers.APIKeyHandler.CreateAPIKey)
+			apiKeys.GET("", handlers.APIKeyHandler.ListAPIKeys)
+			apiKeys.GET("/:id", handlers.APIKeyHandler.GetAPIKey)
+			apiKeys.PUT("/:id", handlers.APIKeyHandler.UpdateAPIKey)
+			apiKeys.DELETE("/:id", handlers.APIKeyHandler.DeleteAPIKey)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin")
+		admin.Use(authRequired, middleware.RequireRole(model.RoleAdmin), middleware.DenyImpersonation())
+		if cfg.Auth.RequireAdminMFA {
+			admin.Use(middleware.RequireMFA())
+		}
+		{
+			admin.GET("/roles", handlers.RoleHandler.ListRoles)
+			admin.GET("/users/:id/roles", handlers.RoleHandler.GetUserRoles)
+			admin.POST("/users/:id/roles", handlers.RoleHandler.AssignRole)
+			admin.DELETE("/users/:id/roles/:role", handlers.RoleHandler.RevokeRole)
+			admin.GET("/lockouts", handlers.LockoutHandler.ListLockouts)
+			admin.DELETE("/lockouts/:type/:key", handlers.LockoutHandler.ClearLockout)
+			admin.POST("/impersonate/:userId", handlers.ImpersonationHandler.Impersonate)
+			admin.GET("/audit-logs", handlers.AuditHandler.ListAuditLogs)
+			admin.POST("/oauth-clients", handlers.OAuthClientHandler.CreateOAuthClient)
+			admin.GET("/oauth-clients", handlers.OAuthClientHandler.ListOAuthClients)
+			admin.DELETE("/oauth-clients/:id", handlers.OAuthClientHandler.DeleteOAuthClient)
+			admin.GET("/trash/users", handlers.UserHandler.ListDeletedUsers)
+			admin.POST("/trash/users/:id/restore", handlers.UserHandler.RestoreUser)
+			admin.GET("/trash/products", handlers.ProductHandler.ListDeletedProducts)
+			admin.POST("/trash/products/:id/restore", handlers.ProductHandler.RestoreProduct)
 		}
 	}
 
@@ -136,6 +237,11 @@ func main() {
 		Handler: r,
 	}
 
+	// Purge expired records from the trash and health-check the read replicas in the background
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	go app.PurgeService.Run(backgroundCtx)
+	go app.Replicas.Run(backgroundCtx)
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info(fmt.Sprintf("Server starting on %s", addr))
@@ -150,6 +256,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopBackground()
 
 	// Set shutdown timeout from config, default to 30 seconds
 	shutdownTimeout := cfg.Server.ShutdownTimeout
@@ -168,3 +275,25 @@ func main() {
 
 	logger.Info("Server exited gracefully")
 }
+
+// migrateDatabase applies the pending SQL migrations
+func migrateDatabase(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	driver := db.Dialector.Name()
+	fsys, err := migrations.For(driver)
+	if err != nil {
+		return err
+	}
+	migrator, err := migrate.New(sqlDB, driver, fsys)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		logger.Info("Applied migration", zap.Uint64("version", m.Version), zap.String("name", m.Name))
+	}
+	return err
+}
@@ -8,11 +8,20 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Logger   LoggerConfig   `mapstructure:"logger"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Logger     LoggerConfig     `mapstructure:"logger"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	RBAC       RBACConfig       `mapstructure:"rbac"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Notifier   NotifierConfig   `mapstructure:"notifier"`
+	Lockout    LockoutConfig    `mapstructure:"lockout"`
+	OIDC       OIDCConfig       `mapstructure:"oidc"`
+	Password   PasswordConfig   `mapstructure:"password"`
+	CORS       CORSConfig       `mapstructure:"cors"`
+	Trash      TrashConfig      `mapstructure:"trash"`
+	Pagination PaginationConfig `mapstructure:"pagination"`
 }
 
 type ServerConfig struct {
@@ -22,14 +31,38 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
+	Driver          string `mapstructure:"driver"` // mysql, postgres or sqlite, default mysql
 	Host            string `mapstructure:"host"`
 	Port            int    `mapstructure:"port"`
 	User            string `mapstructure:"user"`
 	Password        string `mapstructure:"password"`
 	Database        string `mapstructure:"database"`
+	SSLMode         string `mapstructure:"ssl_mode"` // PostgreSQL sslmode, default disable
+	Path            string `mapstructure:"path"`     // SQLite database file, ":memory:" for an in-memory database
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	MigrateOnStart  bool   `mapstructure:"migrate_on_start"` // Apply pending SQL migrations at startup
+
+	// Read replicas serve lists, counts and lookups by ID outside transactions
+	Replicas       []ReplicaConfig `mapstructure:"replicas"`
+	ReplicaCheck   int             `mapstructure:"replica_check_interval"` // Seconds between replica health checks, default 10
+	ReplicaMaxLag  int             `mapstructure:"replica_max_lag"`        // Seconds a replica may lag behind the primary before it leaves the rotation, default 30
+	ReadYourWrites int             `mapstructure:"read_your_writes"`       // Seconds a client reads from the primary after a change, default 5
+
+	// Statements are logged at debug level, slow and failed ones as warnings and errors
+	SlowQuery int  `mapstructure:"slow_query_ms"` // Milliseconds after which a statement is slow, default 200
+	LogParams bool `mapstructure:"log_params"`    // Log the bound parameters instead of placeholders, exposes password hashes and MFA secrets
+}
+
+// ReplicaConfig is a read replica of the database. Empty fields are taken
+// from the primary.
+type ReplicaConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	Path     string `mapstructure:"path"` // SQLite replica file
 }
 
 type RedisConfig struct {
@@ -53,9 +86,113 @@ type LoggerConfig struct {
 
 // JWTConfig holds JWT authentication configuration
 type JWTConfig struct {
-	Secret          string `mapstructure:"secret"`           // Secret key for signing tokens
-	ExpirationHours int    `mapstructure:"expiration_hours"` // Token expiration time in hours
-	Issuer          string `mapstructure:"issuer"`           // Token issuer
+	Secret                  string          `mapstructure:"secret"`                    // Secret key for signing tokens with HS256
+	Algorithm               string          `mapstructure:"algorithm"`                 // Signing algorithm: HS256 (default), RS256, ES256 or EdDSA
+	SigningKeyID            string          `mapstructure:"signing_key_id"`            // ID of the key in keys used to sign new tokens (asymmetric algorithms only)
+	Keys                    []JWTKeyConfig  `mapstructure:"keys"`                      // Signing and verification keys (asymmetric algorithms only)
+	ExpirationHours         int             `mapstructure:"expiration_hours"`          // Token expiration time in hours, used when access_expiration_minutes is not set
+	AccessExpirationMinutes int             `mapstructure:"access_expiration_minutes"` // Access token expiration time in minutes
+	RefreshExpirationHours  int             `mapstructure:"refresh_expiration_hours"`  // Refresh token expiration time in hours, default 168
+	Issuer                  string          `mapstructure:"issuer"`                    // Token issuer
+	Cookie                  JWTCookieConfig `mapstructure:"cookie"`                    // Cookie-based sessions for browser clients
+}
+
+// JWTCookieConfig holds the settings for delivering tokens as cookies.
+// Cookie-authenticated requests are protected by a double-submit CSRF token.
+type JWTCookieConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`      // Set tokens as HttpOnly cookies on login instead of returning them, and accept the access token cookie
+	AccessName  string `mapstructure:"access_name"`  // Access token cookie name, default access_token
+	RefreshName string `mapstructure:"refresh_name"` // Refresh token cookie name, default refresh_token
+	RefreshPath string `mapstructure:"refresh_path"` // Only requests below this path carry the refresh token cookie, default /api/v1/auth
+	CSRFName    string `mapstructure:"csrf_name"`    // CSRF token cookie name, default csrf_token
+	Domain      string `mapstructure:"domain"`       // Cookie domain, empty limits the cookies to the API host
+	SameSite    string `mapstructure:"same_site"`    // lax (default), strict or none
+	Insecure    bool   `mapstructure:"insecure"`     // Drop the Secure attribute, only for local development over plain HTTP
+}
+
+// JWTKeyConfig describes a key pair stored as PEM files.
+// Keys that are being rotated out only need public_key_file to keep verifying tokens.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`               // Key ID written to the kid header
+	PrivateKeyFile string `mapstructure:"private_key_file"` // PEM encoded private key (PKCS#1, SEC 1 or PKCS#8)
+	PublicKeyFile  string `mapstructure:"public_key_file"`  // PEM encoded public key (PKIX), used when no private key is set
+}
+
+// RBACConfig holds role-based access control configuration
+type RBACConfig struct {
+	BootstrapAdminEmail string `mapstructure:"bootstrap_admin_email"` // User that becomes admin while no admin exists
+}
+
+// AuthConfig holds account recovery, email verification, MFA, impersonation and OAuth client configuration
+type AuthConfig struct {
+	PasswordResetURL          string `mapstructure:"password_reset_url"`           // Page that handles reset links, the token is appended as ?token=
+	PasswordResetTTLMinutes   int    `mapstructure:"password_reset_ttl_minutes"`   // Reset token validity period in minutes, default 30
+	EmailVerificationURL      string `mapstructure:"email_verification_url"`       // Page that handles verification links, the token is appended as ?token=
+	EmailVerificationTTLHours int    `mapstructure:"email_verification_ttl_hours"` // Verification token validity period in hours, default 24
+	RequireVerifiedEmail      bool   `mapstructure:"require_verified_email"`       // Block login until the account's email address is verified
+	MFAIssuer                 string `mapstructure:"mfa_issuer"`                   // Issuer shown in authenticator apps, default go-web-template
+	RequireAdminMFA           bool   `mapstructure:"require_admin_mfa"`            // Require a token issued after MFA for the admin endpoints
+	ImpersonationTTLMinutes   int    `mapstructure:"impersonation_ttl_minutes"`    // Lifetime of impersonation tokens in minutes, default 15
+	ClientTokenTTLMinutes     int    `mapstructure:"client_token_ttl_minutes"`     // Lifetime of OAuth client credentials tokens in minutes, default 60
+}
+
+// NotifierConfig holds configuration for delivering messages to users
+type NotifierConfig struct {
+	Driver   string `mapstructure:"driver"`    // log (default) or file
+	FilePath string `mapstructure:"file_path"` // Output file for the file driver
+}
+
+// LockoutConfig holds brute-force protection thresholds for login
+type LockoutConfig struct {
+	MaxAttemptsPerEmail int `mapstructure:"max_attempts_per_email"` // Failed logins for one email before it is locked, default 5
+	MaxAttemptsPerIP    int `mapstructure:"max_attempts_per_ip"`    // Failed logins from one client IP before it is locked, default 20
+	WindowMinutes       int `mapstructure:"window_minutes"`         // Period in minutes failed attempts are counted over, default 15
+	BaseLockoutSeconds  int `mapstructure:"base_lockout_seconds"`   // First lockout duration, doubled for every further failure, default 30
+	MaxLockoutMinutes   int `mapstructure:"max_lockout_minutes"`    // Upper bound of the lockout duration in minutes, default 60
+}
+
+// OIDCConfig holds the OpenID Connect provider users can sign in with
+type OIDCConfig struct {
+	Enabled          bool     `mapstructure:"enabled"`            // Enable login through the provider
+	Issuer           string   `mapstructure:"issuer"`             // Issuer URL, the provider configuration is discovered from it
+	ClientID         string   `mapstructure:"client_id"`          // Client ID registered at the provider
+	ClientSecret     string   `mapstructure:"client_secret"`      // Client secret registered at the provider
+	RedirectURL      string   `mapstructure:"redirect_url"`       // Callback URL registered at the provider, e.g. https://api.example.com/api/v1/auth/oidc/callback
+	Scopes           []string `mapstructure:"scopes"`             // Requested scopes, default openid, email and profile
+	AllowSignup      bool     `mapstructure:"allow_signup"`       // Create a local user on first login when no account has the email
+	AllowedDomains   []string `mapstructure:"allowed_domains"`    // Email domains allowed to sign in, empty allows any
+	TrustProviderMFA bool     `mapstructure:"trust_provider_mfa"` // Accept an mfa in the provider's amr claim as second factor, instead of asking for the local one
+}
+
+// PasswordConfig holds the password policy and the password hashing parameters
+type PasswordConfig struct {
+	MinLength         int    `mapstructure:"min_length"`         // Minimum number of characters, default 8
+	RequireUpper      bool   `mapstructure:"require_upper"`      // Require an uppercase letter
+	RequireLower      bool   `mapstructure:"require_lower"`      // Require a lowercase letter
+	RequireDigit      bool   `mapstructure:"require_digit"`      // Require a digit
+	RequireSymbol     bool   `mapstructure:"require_symbol"`     // Require a character that is neither a letter nor a digit
+	DenylistFile      string `mapstructure:"denylist_file"`      // File with one rejected password per line, empty disables the check
+	Algorithm         string `mapstructure:"algorithm"`          // Hash for new passwords: argon2id (default) or bcrypt
+	BcryptCost        int    `mapstructure:"bcrypt_cost"`        // bcrypt cost, default 10
+	Argon2Memory      int    `mapstructure:"argon2_memory"`      // argon2id memory in KiB, default 65536
+	Argon2Iterations  int    `mapstructure:"argon2_iterations"`  // argon2id passes over the memory, default 3
+	Argon2Parallelism int    `mapstructure:"argon2_parallelism"` // argon2id threads, default 2
+}
+
+// CORSConfig holds Cross-Origin Resource Sharing configuration
+type CORSConfig struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // Origins allowed to send credentials, empty allows any origin without credentials
+}
+
+// TrashConfig holds how long soft deleted records are kept before they are purged
+type TrashConfig struct {
+	RetentionDays        int `mapstructure:"retention_days"`         // Days deleted users and products stay restorable, default 30
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"` // How often expired records are purged, default 60
+}
+
+// PaginationConfig holds list pagination configuration
+type PaginationConfig struct {
+	CursorSecret string `mapstructure:"cursor_secret"` // Signs list cursors, required and separate from the JWT secret
 }
 
 // Load loads configuration from file and environment variables
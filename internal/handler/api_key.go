@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles HTTP requests for managing the current user's API keys
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create an API key for the current user. Scopes must be permissions the user has. The key is returned only once.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param apiKey body model.CreateAPIKeyRequest true "API key information"
+// @Success 200 {object} response.Response{data=model.CreateAPIKeyResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	key, err := h.apiKeyService.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, key)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Get the current user's API keys
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.APIKey}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+// GetAPIKey godoc
+// @Summary Get an API key
+// @Description Get one of the current user's API keys by ID
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.Response{data=model.APIKey}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /api/v1/api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid api key id"))
+		return
+	}
+
+	key, err := h.apiKeyService.GetByID(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, key)
+}
+
+// UpdateAPIKey godoc
+// @Summary Update an API key
+// @Description Rename one of the current user's API keys or change its scopes or expiry
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Param apiKey body model.UpdateAPIKeyRequest true "API key information"
+// @Success 200 {object} response.Response{data=model.APIKey}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/api-keys/{id} [put]
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid api key id"))
+		return
+	}
+
+	var req model.UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	key, err := h.apiKeyService.Update(c.Request.Context(), userID, uint(id), &req)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, key)
+}
+
+// DeleteAPIKey godoc
+// @Summary Delete an API key
+// @Description Revoke one of the current user's API keys
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/api-keys/{id} [delete]
+func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid api key id"))
+		return
+	}
+
+	if err := h.apiKeyService.Delete(c.Request.Context(), userID, uint(id)); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "api key deleted successfully", nil)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles HTTP requests for reading the audit log
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary List audit logs
+// @Description Get a paginated list of recorded administrative actions, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	entries, total, err := h.auditService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, map[string]interface{}{
+		"audit_logs": entries,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+	})
+}
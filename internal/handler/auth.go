@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"net/http"
+	"slices"
+
 	"github.com/IndigoCloud6/go-web-template/internal/config"
 	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
 	"github.com/IndigoCloud6/go-web-template/internal/service"
 	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
 	"github.com/IndigoCloud6/go-web-template/pkg/response"
@@ -11,15 +15,23 @@ import (
 
 // AuthHandler handles HTTP requests for authentication
 type AuthHandler struct {
-	authService service.AuthService
-	jwtConfig   *config.JWTConfig
+	authService         service.AuthService
+	tokenService        service.TokenService
+	verificationService service.EmailVerificationService
+	mfaService          service.MFAService
+	oidcService         service.OIDCService
+	jwtConfig           *config.JWTConfig
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService service.AuthService, jwtConfig *config.JWTConfig) *AuthHandler {
+func NewAuthHandler(authService service.AuthService, tokenService service.TokenService, verificationService service.EmailVerificationService, mfaService service.MFAService, oidcService service.OIDCService, jwtConfig *config.JWTConfig) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		jwtConfig:   jwtConfig,
+		authService:         authService,
+		tokenService:        tokenService,
+		verificationService: verificationService,
+		mfaService:          mfaService,
+		oidcService:         oidcService,
+		jwtConfig:           jwtConfig,
 	}
 }
 
@@ -31,7 +43,9 @@ type LoginRequest struct {
 
 // LoginResponse represents the login response
 type LoginResponse struct {
-	Token string `json:"token"`
+	model.TokenPair
+	// Token mirrors AccessToken for clients written against the single-token API
+	Token string `json:"token,omitempty"`
 	User  struct {
 		ID    uint   `json:"id"`
 		Name  string `json:"name"`
@@ -41,7 +55,8 @@ type LoginResponse struct {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return an access token together with a refresh token.
+// @Description Users with two-factor authentication enabled get a model.MFAChallenge instead, to be completed at /api/v1/auth/mfa/verify.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -49,6 +64,7 @@ type LoginResponse struct {
 // @Success 200 {object} response.Response{data=LoginResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -59,21 +75,146 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Authenticate user
-	user, err := h.authService.Authenticate(c.Request.Context(), req.Email, req.Password)
+	user, err := h.authService.Authenticate(c.Request.Context(), req.Email, req.Password, c.ClientIP())
 	if err != nil {
 		response.ErrorFromAppError(c, err)
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateToken(h.jwtConfig, user.ID, user.Email)
+	// A second factor is required before any token is issued
+	if user.MFAEnabled {
+		challenge, err := h.mfaService.CreateChallenge(c.Request.Context(), user)
+		if err != nil {
+			response.ErrorFromAppError(c, err)
+			return
+		}
+		response.Success(c, challenge)
+		return
+	}
+
+	h.issueLoginTokens(c, user, []string{model.AMRPassword})
+}
+
+// OIDCLogin godoc
+// @Summary Start OpenID Connect login
+// @Description Redirect to the configured OpenID Connect provider. The provider redirects back to the callback endpoint.
+// @Description The state of the login is kept in the HttpOnly oidc_state cookie until the callback.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.oidcService.AuthCodeURL(c.Request.Context())
 	if err != nil {
-		response.ErrorFromAppError(c, apperrors.NewInternalErrorWithCause("failed to generate token", err))
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// The callback is only accepted in the browser that started the login
+	middleware.SetOIDCStateCookie(c, h.jwtConfig, state, service.OIDCStateTTL)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary Complete OpenID Connect login
+// @Description Exchange the authorization code returned by the provider for an access token and a refresh token.
+// @Description Only accepted in the browser that started the login, which keeps its state in the oidc_state cookie.
+// @Description The provider account is linked to the user with the same verified email on first login.
+// @Description Users with two-factor authentication enabled get a model.MFAChallenge unless the provider reports MFA and oidc.trust_provider_mfa is set.
+// @Tags auth
+// @Produce json
+// @Param state query string true "State returned by the provider"
+// @Param code query string true "Authorization code returned by the provider"
+// @Success 200 {object} response.Response{data=LoginResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	browserState := middleware.OIDCStateFromCookie(c)
+	middleware.ClearOIDCStateCookie(c, h.jwtConfig)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("identity provider returned an error: "+providerErr))
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("state and code are required"))
+		return
+	}
+
+	user, amr, err := h.oidcService.Complete(c.Request.Context(), state, browserState, code)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// The local second factor is still required unless the provider is trusted
+	// to have asked for one
+	if user.MFAEnabled && !slices.Contains(amr, model.AMRMFA) {
+		challenge, err := h.mfaService.CreateChallenge(c.Request.Context(), user)
+		if err != nil {
+			response.ErrorFromAppError(c, err)
+			return
+		}
+		response.Success(c, challenge)
+		return
+	}
+
+	h.issueLoginTokens(c, user, amr)
+}
+
+// VerifyMFA godoc
+// @Summary Complete two-factor login
+// @Description Exchange the mfa_token returned by login and a TOTP or recovery code for an access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.MFAVerifyRequest true "MFA token and code"
+// @Success 200 {object} response.Response{data=LoginResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req model.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	user, err := h.mfaService.VerifyChallenge(c.Request.Context(), req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	h.issueLoginTokens(c, user, []string{model.AMRPassword, model.AMROTP, model.AMRMFA})
+}
+
+// issueLoginTokens issues a token pair for an authenticated user and writes the login response
+func (h *AuthHandler) issueLoginTokens(c *gin.Context, user *model.User, amr []string) {
+	tokens, err := h.tokenService.IssueTokenPair(c.Request.Context(), user, amr, clientInfo(c))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	tokens, err = h.deliverTokens(c, tokens)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
 		return
 	}
 
 	resp := LoginResponse{
-		Token: token,
+		TokenPair: *tokens,
+		Token:     tokens.AccessToken,
 	}
 	resp.User.ID = user.ID
 	resp.User.Name = user.Name
@@ -82,39 +223,316 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	response.Success(c, resp)
 }
 
+// deliverTokens moves the tokens into cookies when cookie sessions are enabled,
+// so scripts in the browser never see them. It returns the token pair to put in
+// the response body.
+func (h *AuthHandler) deliverTokens(c *gin.Context, tokens *model.TokenPair) (*model.TokenPair, error) {
+	if !middleware.CookieSessionsEnabled(h.jwtConfig) {
+		return tokens, nil
+	}
+
+	if err := middleware.SetAuthCookies(c, h.jwtConfig, tokens); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to set session cookies", err)
+	}
+	return &model.TokenPair{
+		TokenType: tokens.TokenType,
+		ExpiresIn: tokens.ExpiresIn,
+	}, nil
+}
+
 // RefreshToken godoc
 // @Summary Refresh JWT token
-// @Description Refresh an existing valid JWT token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token.
+// @Description Each refresh token can only be used once; reusing one revokes all tokens issued from the same login.
+// @Description With cookie sessions the refresh token cookie is used when the body is empty, and the new tokens are set as cookies.
 // @Tags auth
+// @Accept json
 // @Produce json
-// @Security BearerAuth
-// @Success 200 {object} response.Response{data=map[string]string}
+// @Param refresh body model.RefreshTokenRequest false "Refresh token"
+// @Success 200 {object} response.Response{data=model.TokenPair}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req model.RefreshTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+			return
+		}
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = middleware.RefreshTokenFromCookie(c, h.jwtConfig)
+	}
+	if req.RefreshToken == "" {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("refresh_token is required"))
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	tokens, err = h.deliverTokens(c, tokens)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Send a password reset link to the email address. The response is the same whether or not an account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req model.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "if an account exists for this email, a reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a reset token. The token can be used once and all existing sessions are logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "password reset successfully", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm an email address with the token from a verification link.
+// @Description For a pending email change the new address replaces the current one.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req model.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	user, err := h.verificationService.Verify(c.Request.Context(), req.Token)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// Remove password from response
+	user.Password = ""
+	response.Success(c, user)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link for an unverified account or a pending email change.
+// @Description The response is the same whether or not an account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ResendVerificationRequest true "Account email"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/email/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req model.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.verificationService.Resend(c.Request.Context(), req.Email); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "if the email needs verification, a new link has been sent", nil)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description End the current session, revoking the current access token and the refresh tokens issued with it
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logout body model.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, exists := middleware.GetClaimsFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+			return
+		}
+	}
+
+	if err := h.tokenService.Revoke(c.Request.Context(), claims); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	if claims.SessionID != "" {
+		err := h.tokenService.RevokeSession(c.Request.Context(), claims.UserID, claims.SessionID)
+		if err != nil && !apperrors.IsNotFoundError(err) {
+			response.ErrorFromAppError(c, err)
+			return
+		}
+	}
+
+	if req.RefreshToken == "" {
+		req.RefreshToken = middleware.RefreshTokenFromCookie(c, h.jwtConfig)
+	}
+	if req.RefreshToken != "" {
+		if err := h.tokenService.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+			response.ErrorFromAppError(c, err)
+			return
+		}
+	}
+
+	h.clearCookies(c)
+	response.SuccessWithMessage(c, "logged out successfully", nil)
+}
+
+// LogoutAll godoc
+// @Summary Log out from all sessions
+// @Description Revoke every access and refresh token issued to the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	userID, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
 		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
 		return
 	}
 
-	email, exists := middleware.GetEmailFromContext(c)
+	if err := h.tokenService.RevokeAllForUser(c.Request.Context(), userID); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	h.clearCookies(c)
+	response.SuccessWithMessage(c, "all sessions logged out successfully", nil)
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description List the current user's active logins with their device, user agent, IP address and last use
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.Session}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	claims, exists := middleware.GetClaimsFromContext(c)
 	if !exists {
 		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
 		return
 	}
 
-	// Generate new token
-	token, err := middleware.GenerateToken(h.jwtConfig, userID, email)
+	sessions, err := h.tokenService.ListSessions(c.Request.Context(), claims.UserID)
 	if err != nil {
-		response.ErrorFromAppError(c, apperrors.NewInternalErrorWithCause("failed to generate token", err))
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == claims.SessionID
+	}
+
+	response.Success(c, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log out one of the current user's sessions. Its tokens stop working immediately.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	if err := h.tokenService.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		response.ErrorFromAppError(c, err)
 		return
 	}
 
-	response.Success(c, map[string]string{
-		"token": token,
-	})
+	response.SuccessWithMessage(c, "session revoked successfully", nil)
 }
 
 // GetCurrentUser godoc
@@ -139,10 +557,161 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, map[string]interface{}{
+	data := map[string]interface{}{
 		"id":    user.ID,
 		"name":  user.Name,
 		"email": user.Email,
 		"age":   user.Age,
-	})
+	}
+	// Lets clients show a banner while an admin acts as the user
+	if claims, ok := middleware.GetClaimsFromContext(c); ok && claims.IsImpersonated() {
+		data["impersonated_by"] = claims.Actor.UserID
+	}
+
+	response.Success(c, data)
+}
+
+// UpdateCurrentUser godoc
+// @Summary Update current user
+// @Description Update the current user's name, email or age. A new email is stored as pending_email until it has been verified.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/me [patch]
+func (h *AuthHandler) UpdateCurrentUser(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	user, err := h.authService.UpdateProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// Remove password from response
+	user.Password = ""
+	response.Success(c, user)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the current user's password. Requires the current password; all other sessions are logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/me/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	claims, exists := middleware.GetClaimsFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.authService.ChangePassword(c.Request.Context(), claims.UserID, claims.SessionID, c.ClientIP(), &req); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "password changed successfully", nil)
+}
+
+// DeleteCurrentUser godoc
+// @Summary Delete current user
+// @Description Delete the current user's account. Requires the password; all tokens of the user are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.DeleteAccountRequest true "Current password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/me [delete]
+func (h *AuthHandler) DeleteCurrentUser(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.authService.DeleteAccount(c.Request.Context(), userID, req.Password, c.ClientIP()); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	h.clearCookies(c)
+	response.SuccessWithMessage(c, "account deleted successfully", nil)
+}
+
+// clearCookies removes the session cookies when cookie sessions are enabled
+func (h *AuthHandler) clearCookies(c *gin.Context) {
+	if middleware.CookieSessionsEnabled(h.jwtConfig) {
+		middleware.ClearAuthCookies(c, h.jwtConfig)
+	}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens, looked up by the token's kid header.
+// @Description The set is empty when tokens are signed with HS256.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} middleware.JWKS
+// @Failure 500 {object} response.Response
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	keys, err := middleware.Keys(h.jwtConfig)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewInternalErrorWithCause("failed to load token keys", err))
+		return
+	}
+
+	// Served as a bare key set, as expected by JWT libraries, rather than wrapped in response.Response
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keys.JWKS())
+}
+
+// clientInfo describes the client of the request for session tracking
+func clientInfo(c *gin.Context) *model.ClientInfo {
+	return &model.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
 }
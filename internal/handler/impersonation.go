@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationHandler handles HTTP requests for admins acting as another user
+type ImpersonationHandler struct {
+	impersonationService service.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationService service.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonate godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token that acts as the user, for support (admin only). The token carries the admin in its act claim, cannot be refreshed and cannot change the password, MFA or API keys. Every impersonation is written to the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param userId path int true "User ID"
+// @Param request body model.ImpersonateRequest false "Reason for the impersonation"
+// @Success 200 {object} response.Response{data=model.ImpersonationToken}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/impersonate/{userId} [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	actorID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid user id"))
+		return
+	}
+
+	var req model.ImpersonateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+			return
+		}
+	}
+
+	token, err := h.impersonationService.Impersonate(c.Request.Context(), actorID, uint(targetID), req.Reason, clientInfo(c))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, token)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler handles HTTP requests for inspecting and clearing login lockouts
+type LockoutHandler struct {
+	limiter service.LoginLimiter
+}
+
+// NewLockoutHandler creates a new lockout handler
+func NewLockoutHandler(limiter service.LoginLimiter) *LockoutHandler {
+	return &LockoutHandler{
+		limiter: limiter,
+	}
+}
+
+// ListLockouts godoc
+// @Summary List login lockouts
+// @Description Get all emails and client IPs that are currently locked out of login (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.Lockout}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/lockouts [get]
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	lockouts, err := h.limiter.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, lockouts)
+}
+
+// ClearLockout godoc
+// @Summary Clear a login lockout
+// @Description Lift the lockout of an email or client IP and reset its failed attempts (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Lockout type" Enums(email, ip)
+// @Param key path string true "Email address or client IP"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/lockouts/{type}/{key} [delete]
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	if err := h.limiter.Clear(c.Request.Context(), c.Param("type"), c.Param("key")); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "lockout cleared successfully", nil)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler handles HTTP requests for managing two-factor authentication
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+// NewMFAHandler creates a new MFA handler
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+// Enroll godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and otpauth URI for an authenticator app. It takes effect once confirmed.
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.MFAEnrollment}
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// ConfirmEnrollment godoc
+// @Summary Confirm two-factor enrollment
+// @Description Enable two-factor authentication with a first code from the authenticator app. Returns recovery codes, shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "TOTP code"
+// @Success 200 {object} response.Response{data=model.RecoveryCodesResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/mfa/enroll/confirm [post]
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication after checking a TOTP or recovery code
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), userID, req.Code); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after checking a TOTP or recovery code. Returns the new codes, shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response{data=model.RecoveryCodesResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
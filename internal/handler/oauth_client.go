@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/service"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthClientHandler handles the OAuth2 token endpoint and the management of OAuth clients
+type OAuthClientHandler struct {
+	oauthClientService service.OAuthClientService
+}
+
+// NewOAuthClientHandler creates a new OAuth client handler
+func NewOAuthClientHandler(oauthClientService service.OAuthClientService) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		oauthClientService: oauthClientService,
+	}
+}
+
+// Token godoc
+// @Summary OAuth2 token endpoint
+// @Description Issue an access token to a registered service with the client_credentials grant (RFC 6749, section 4.4).
+// @Description Clients authenticate with HTTP Basic (client_secret_basic) or with client_id and client_secret form fields (client_secret_post).
+// @Description Responses use the OAuth2 format instead of the API's response envelope.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Must be client_credentials"
+// @Param scope formData string false "Space separated scopes, defaults to all scopes of the client"
+// @Param client_id formData string false "Client ID, when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret, when not using HTTP Basic"
+// @Success 200 {object} model.ClientTokenResponse
+// @Failure 400 {object} model.OAuthErrorResponse
+// @Failure 401 {object} model.OAuthErrorResponse
+// @Failure 500 {object} model.OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthClientHandler) Token(c *gin.Context) {
+	// Token responses must not be cached (RFC 6749, section 5.1)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if grantType := c.PostForm("grant_type"); grantType == "" {
+		oauthError(c, http.StatusBadRequest, model.OAuthErrorInvalidRequest, "grant_type is required")
+		return
+	} else if grantType != model.GrantTypeClientCredentials {
+		oauthError(c, http.StatusBadRequest, model.OAuthErrorUnsupportedGrantType, "only client_credentials is supported")
+		return
+	}
+
+	clientID, clientSecret, basic := c.Request.BasicAuth()
+	if basic {
+		// Basic credentials are form-encoded before being base64 encoded (RFC 6749, section 2.3.1)
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		clientSecret, errSecret = url.QueryUnescape(clientSecret)
+		if errID != nil || errSecret != nil {
+			oauthError(c, http.StatusBadRequest, model.OAuthErrorInvalidRequest, "malformed client credentials")
+			return
+		}
+		if c.PostForm("client_secret") != "" {
+			oauthError(c, http.StatusBadRequest, model.OAuthErrorInvalidRequest, "use only one client authentication method")
+			return
+		}
+	} else {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		oauthError(c, http.StatusUnauthorized, model.OAuthErrorInvalidClient, "client authentication is required")
+		return
+	}
+
+	token, err := h.oauthClientService.IssueToken(c.Request.Context(), clientID, clientSecret, c.PostForm("scope"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, token)
+	case apperrors.IsUnauthorizedError(err):
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, http.StatusUnauthorized, model.OAuthErrorInvalidClient, apperrors.GetErrorMessage(err))
+	case apperrors.IsValidationError(err):
+		oauthError(c, http.StatusBadRequest, model.OAuthErrorInvalidScope, apperrors.GetErrorMessage(err))
+	default:
+		oauthError(c, http.StatusInternalServerError, model.OAuthErrorServerError, "")
+	}
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, model.OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// CreateOAuthClient godoc
+// @Summary Register an OAuth client
+// @Description Register a service that obtains tokens from /oauth/token with the client credentials grant (admin only). The client secret is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client body model.CreateOAuthClientRequest true "Client name and allowed scopes"
+// @Success 200 {object} response.Response{data=model.CreateOAuthClientResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/oauth-clients [post]
+func (h *OAuthClientHandler) CreateOAuthClient(c *gin.Context) {
+	var req model.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid request body", err))
+		return
+	}
+
+	client, err := h.oauthClientService.Create(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, client)
+}
+
+// ListOAuthClients godoc
+// @Summary List OAuth clients
+// @Description List the registered OAuth clients (admin only). Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.OAuthClient}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/oauth-clients [get]
+func (h *OAuthClientHandler) ListOAuthClients(c *gin.Context) {
+	clients, err := h.oauthClientService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, clients)
+}
+
+// DeleteOAuthClient godoc
+// @Summary Delete an OAuth client
+// @Description Delete an OAuth client and revoke the tokens issued to it (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "OAuth client ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/oauth-clients/{id} [delete]
+func (h *OAuthClientHandler) DeleteOAuthClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid oauth client id"))
+		return
+	}
+
+	if err := h.oauthClientService.Delete(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "oauth client deleted successfully", nil)
+}
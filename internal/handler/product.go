@@ -79,30 +79,56 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 
 // ListProducts godoc
 // @Summary List products
-// @Description Get a paginated list of products
+// @Description Get a paginated list of products, optionally filtered and sorted.
+// @Description Pages are numbered, or follow next_cursor and prev_cursor, which stay stable while rows are inserted or deleted.
 // @Tags products
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
+// @Param q query string false "Name or description contains the text"
+// @Param name query string false "Name contains the text"
+// @Param price_min query number false "Minimum price, inclusive"
+// @Param price_max query number false "Maximum price, inclusive"
+// @Param stock_lt query int false "Stock below the value"
+// @Param created_after query string false "Created at or after, RFC 3339" format(date-time)
+// @Param created_before query string false "Created before, RFC 3339" format(date-time)
+// @Param sort query string false "Comma separated fields of id, name, price, stock, created_at, updated_at; prefix with - for descending" example(-price,name)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, replaces page"
+// @Param count query bool false "Return the total, defaults to true without a cursor and false with one"
 // @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/products [get]
 func (h *ProductHandler) ListProducts(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	var req model.ListProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid query parameters", err))
+		return
+	}
 
-	products, total, err := h.productService.List(c.Request.Context(), page, pageSize)
+	products, info, err := h.productService.List(c.Request.Context(), &req)
 	if err != nil {
 		response.ErrorFromAppError(c, err)
 		return
 	}
 
-	response.Success(c, map[string]interface{}{
+	data := map[string]interface{}{
 		"products":  products,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+		"page_size": req.PageSize,
+	}
+	if req.Cursor == "" {
+		data["page"] = req.Page
+	}
+	if info.Total != nil {
+		data["total"] = *info.Total
+	}
+	if info.NextCursor != "" {
+		data["next_cursor"] = info.NextCursor
+	}
+	if info.PrevCursor != "" {
+		data["prev_cursor"] = info.PrevCursor
+	}
+	response.Success(c, data)
 }
 
 // UpdateProduct godoc
@@ -143,7 +169,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 // DeleteProduct godoc
 // @Summary Delete a product
-// @Description Delete a product by ID
+// @Description Move a product to the trash, it can be restored until the retention period ends
 // @Tags products
 // @Produce json
 // @Param id path int true "Product ID"
@@ -167,3 +193,64 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	response.SuccessWithMessage(c, "product deleted successfully", nil)
 }
+
+// ListDeletedProducts godoc
+// @Summary List deleted products
+// @Description Get a paginated list of products in the trash (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/trash/products [get]
+func (h *ProductHandler) ListDeletedProducts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	products, total, err := h.productService.ListDeleted(c.Request.Context(), page, pageSize)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, map[string]interface{}{
+		"products":  products,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description Take a product out of the trash (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} response.Response{data=model.Product}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/trash/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid product id"))
+		return
+	}
+
+	product, err := h.productService.Restore(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	response.Success(c, product)
+}
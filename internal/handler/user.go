@@ -83,19 +83,34 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 // ListUsers godoc
 // @Summary List users
-// @Description Get a paginated list of users
+// @Description Get a paginated list of users, optionally filtered and sorted.
+// @Description Pages are numbered, or follow next_cursor and prev_cursor, which stay stable while rows are inserted or deleted.
 // @Tags users
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
+// @Param q query string false "Name or email contains the text"
+// @Param name query string false "Name contains the text"
+// @Param email query string false "Exact email"
+// @Param age_min query int false "Minimum age, inclusive"
+// @Param age_max query int false "Maximum age, inclusive"
+// @Param created_after query string false "Created at or after, RFC 3339" format(date-time)
+// @Param created_before query string false "Created before, RFC 3339" format(date-time)
+// @Param sort query string false "Comma separated fields of id, name, email, age, created_at, updated_at; prefix with - for descending" example(-created_at,name)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, replaces page"
+// @Param count query bool false "Return the total, defaults to true without a cursor and false with one"
 // @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/users [get]
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	var req model.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationErrorWithCause("invalid query parameters", err))
+		return
+	}
 
-	users, total, err := h.userService.List(c.Request.Context(), page, pageSize)
+	users, info, err := h.userService.List(c.Request.Context(), &req)
 	if err != nil {
 		response.ErrorFromAppError(c, err)
 		return
@@ -106,17 +121,28 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		user.Password = ""
 	}
 
-	response.Success(c, map[string]interface{}{
+	data := map[string]interface{}{
 		"users":     users,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+		"page_size": req.PageSize,
+	}
+	if req.Cursor == "" {
+		data["page"] = req.Page
+	}
+	if info.Total != nil {
+		data["total"] = *info.Total
+	}
+	if info.NextCursor != "" {
+		data["next_cursor"] = info.NextCursor
+	}
+	if info.PrevCursor != "" {
+		data["prev_cursor"] = info.PrevCursor
+	}
+	response.Success(c, data)
 }
 
 // UpdateUser godoc
 // @Summary Update a user
-// @Description Update a user's information
+// @Description Update a user's information. Admin only, users change their own account through /api/v1/auth/me
 // @Tags users
 // @Accept json
 // @Produce json
@@ -124,6 +150,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 // @Param user body model.UpdateUserRequest true "User information"
 // @Success 200 {object} response.Response{data=model.User}
 // @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/users/{id} [put]
@@ -154,12 +181,13 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 // DeleteUser godoc
 // @Summary Delete a user
-// @Description Delete a user by ID
+// @Description Move a user to the trash, it can be restored until the retention period ends. Admin only, users delete their own account through /api/v1/auth/me
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/users/{id} [delete]
@@ -178,3 +206,71 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	response.SuccessWithMessage(c, "user deleted successfully", nil)
 }
+
+// ListDeletedUsers godoc
+// @Summary List deleted users
+// @Description Get a paginated list of users in the trash (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} response.Response{data=map[string]interface{}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/trash/users [get]
+func (h *UserHandler) ListDeletedUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	users, total, err := h.userService.ListDeleted(c.Request.Context(), page, pageSize)
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// Remove passwords from response
+	for _, user := range users {
+		user.Password = ""
+	}
+
+	response.Success(c, map[string]interface{}{
+		"users":     users,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RestoreUser godoc
+// @Summary Restore a deleted user
+// @Description Take a user out of the trash (admin only). The user has to log in again.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/admin/trash/users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorFromAppError(c, apperrors.NewValidationError("invalid user id"))
+		return
+	}
+
+	user, err := h.userService.Restore(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorFromAppError(c, err)
+		return
+	}
+
+	// Remove password from response
+	user.Password = ""
+	response.Success(c, user)
+}
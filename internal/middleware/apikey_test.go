@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type stubAPIKeyValidator struct {
+	key    string
+	keyID  uint
+	claims *Claims
+}
+
+func (v *stubAPIKeyValidator) ValidateAPIKey(ctx context.Context, key string) (*Claims, uint, error) {
+	if key != v.key {
+		return nil, 0, apperrors.NewUnauthorizedError("invalid or expired api key")
+	}
+	return v.claims, v.keyID, nil
+}
+
+func newAPIKeyTestRouter(cfg *config.JWTConfig, validator APIKeyValidator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(JWTOrAPIKeyAuth(cfg, validator))
+	r.GET("/test", RequirePermission("products:write"), func(c *gin.Context) {
+		userID, _ := GetUserIDFromContext(c)
+		keyID, viaKey := GetAPIKeyIDFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "api_key_id": keyID, "via_key": viaKey})
+	})
+	return r
+}
+
+func TestJWTOrAPIKeyAuth_APIKey(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	validator := &stubAPIKeyValidator{
+		key:    "gwt_abc_secret",
+		keyID:  7,
+		claims: &Claims{UserID: 42, Email: "bot@example.com", Permissions: []string{"products:write"}},
+	}
+	r := newAPIKeyTestRouter(cfg, validator)
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(APIKeyHeader, "gwt_abc_secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	expected := `{"api_key_id":7,"user_id":42,"via_key":true}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestJWTOrAPIKeyAuth_InvalidAPIKey(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newAPIKeyTestRouter(cfg, &stubAPIKeyValidator{key: "gwt_abc_secret"})
+
+	// An invalid key is rejected even when a valid JWT is sent alongside it
+	token, err := GenerateTokenWithClaims(cfg, &Claims{UserID: 1, Permissions: []string{"products:write"}})
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(APIKeyHeader, "gwt_abc_wrong")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
+
+func TestJWTOrAPIKeyAuth_FallsBackToJWT(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newAPIKeyTestRouter(cfg, &stubAPIKeyValidator{key: "gwt_abc_secret"})
+
+	token, err := GenerateTokenWithClaims(cfg, &Claims{UserID: 3, Permissions: []string{"products:write"}})
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	expected := `{"api_key_id":0,"user_id":3,"via_key":false}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/test", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without credentials, got %d", w.Code)
+	}
+}
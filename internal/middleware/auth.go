@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"strings"
 	"time"
 
@@ -11,84 +14,181 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Claims represents the JWT claims structure
+// Claims represents the JWT claims structure.
+// The token ID (jti) is carried in RegisteredClaims.ID.
+// Tokens issued to OAuth clients carry ClientID and Scope instead of a user.
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID      uint     `json:"user_id,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Generation  int64    `json:"gen"` // Per-user (or per-client) token generation, bumped to revoke all its tokens
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	AMR         []string `json:"amr,omitempty"`       // Authentication methods used to log in, e.g. ["pwd"] or ["pwd", "otp", "mfa"]
+	SessionID   string   `json:"sid,omitempty"`       // Login session the token was issued in, revoked together with it
+	Actor       *Actor   `json:"act,omitempty"`       // Set when an admin acts as UserID through impersonation
+	ClientID    string   `json:"client_id,omitempty"` // Set for tokens issued to an OAuth client through the client credentials grant
+	Scope       string   `json:"scope,omitempty"`     // Space separated scopes granted to the OAuth client
 	jwt.RegisteredClaims
 }
 
-// JWTAuth creates a JWT authentication middleware
-func JWTAuth(cfg *config.JWTConfig) gin.HandlerFunc {
+// Actor identifies the admin behind an impersonation token
+type Actor struct {
+	UserID     uint   `json:"user_id"`
+	Email      string `json:"email"`
+	Generation int64  `json:"gen"` // The admin's token generation, revoking the admin's tokens ends the impersonation
+}
+
+// TokenValidator performs additional checks on a token after its signature and
+// expiry have been verified, such as looking it up in a revocation list
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, claims *Claims) error
+}
+
+// JWTAuth creates a JWT authentication middleware.
+// Every validator is consulted after the token has been parsed; the first error aborts the request.
+func JWTAuth(cfg *config.JWTConfig, validators ...TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("authorization header is required"))
+		claims, err := authenticateBearer(c, cfg, validators)
+		if err != nil {
+			response.ErrorFromAppError(c, err)
 			c.Abort()
 			return
 		}
 
-		// Check Bearer token format
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("invalid authorization header format"))
-			c.Abort()
-			return
-		}
+		setClaims(c, claims)
+		c.Next()
+	}
+}
 
-		tokenString := parts[1]
-		claims := &Claims{}
+// authenticateBearer parses and validates the JWT in the Authorization header,
+// or in the access token cookie when cookie sessions are enabled
+func authenticateBearer(c *gin.Context, cfg *config.JWTConfig, validators []TokenValidator) (*Claims, error) {
+	tokenString, err := bearerToken(c, cfg)
+	if err != nil {
+		return nil, err
+	}
 
-		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, apperrors.NewUnauthorizedError("invalid signing method")
-			}
-			return []byte(cfg.Secret), nil
-		})
+	claims := &Claims{}
 
-		if err != nil {
-			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("invalid or expired token"))
-			c.Abort()
-			return
+	keys, err := Keys(cfg)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token keys", err)
+	}
+
+	// Parse and validate token; the key set picks the verification key and
+	// rejects algorithms it was not configured for
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("invalid or expired token")
+	}
+
+	if !token.Valid {
+		return nil, apperrors.NewUnauthorizedError("invalid token")
+	}
+
+	// Reject revoked tokens
+	for _, v := range validators {
+		if err := v.ValidateToken(c.Request.Context(), claims); err != nil {
+			return nil, err
 		}
+	}
 
-		if !token.Valid {
-			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("invalid token"))
-			c.Abort()
-			return
+	return claims, nil
+}
+
+// bearerToken returns the token of the Authorization header. Browser clients
+// without the header are authenticated by the access token cookie.
+func bearerToken(c *gin.Context, cfg *config.JWTConfig) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if token := accessTokenFromCookie(c, cfg); token != "" {
+			return token, nil
 		}
+		return "", apperrors.NewUnauthorizedError("authorization header is required")
+	}
 
-		// Store user information in context for later use
+	// Check Bearer token format
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", apperrors.NewUnauthorizedError("invalid authorization header format")
+	}
+
+	return parts[1], nil
+}
+
+// setClaims stores user information in context for later use.
+// Client tokens set the client ID instead, so handlers that act on the
+// current user reject them.
+func setClaims(c *gin.Context, claims *Claims) {
+	if claims.IsClient() {
+		c.Set("client_id", claims.ClientID)
+	} else {
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
-		c.Set("claims", claims)
-
-		c.Next()
 	}
+	c.Set("claims", claims)
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(cfg *config.JWTConfig, userID uint, email string) (string, error) {
+// AccessTokenTTL returns the lifetime of access tokens issued with the given config
+func AccessTokenTTL(cfg *config.JWTConfig) time.Duration {
+	if cfg.AccessExpirationMinutes > 0 {
+		return time.Duration(cfg.AccessExpirationMinutes) * time.Minute
+	}
+
 	expirationHours := cfg.ExpirationHours
 	if expirationHours <= 0 {
 		expirationHours = 24 // default to 24 hours
 	}
+	return time.Duration(expirationHours) * time.Hour
+}
 
-	claims := &Claims{
+// GenerateToken generates a new JWT token for a user
+func GenerateToken(cfg *config.JWTConfig, userID uint, email string) (string, error) {
+	return GenerateTokenWithClaims(cfg, &Claims{
 		UserID: userID,
 		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expirationHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    cfg.Issuer,
-		},
+	})
+}
+
+// GenerateTokenWithClaims signs the given claims, filling in the token ID,
+// issuer and validity period when they are not set
+func GenerateTokenWithClaims(cfg *config.JWTConfig, claims *Claims) (string, error) {
+	now := time.Now()
+
+	if claims.ID == "" {
+		jti, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = jti
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = cfg.Issuer
+	}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
 	}
+	if claims.NotBefore == nil {
+		claims.NotBefore = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(AccessTokenTTL(cfg)))
+	}
+
+	keys, err := Keys(cfg)
+	if err != nil {
+		return "", err
+	}
+	return keys.Sign(claims)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.Secret))
+// newTokenID returns a random identifier for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // GetUserIDFromContext retrieves the user ID from the gin context
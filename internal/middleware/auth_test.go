@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/IndigoCloud6/go-web-template/internal/config"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -199,3 +201,83 @@ func TestGenerateToken_DefaultExpiration(t *testing.T) {
 		t.Error("Expected non-empty token")
 	}
 }
+
+type revokedTokenValidator struct {
+	revoked map[string]bool
+}
+
+func (v *revokedTokenValidator) ValidateToken(ctx context.Context, claims *Claims) error {
+	if v.revoked[claims.ID] {
+		return apperrors.NewUnauthorizedError("token has been revoked")
+	}
+	return nil
+}
+
+func TestGenerateTokenWithClaims_SetsTokenID(t *testing.T) {
+	cfg := &config.JWTConfig{
+		Secret:          "test-secret-key",
+		ExpirationHours: 24,
+		Issuer:          "test-issuer",
+	}
+
+	first := &Claims{UserID: 123, Email: "test@example.com"}
+	if _, err := GenerateTokenWithClaims(cfg, first); err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+	second := &Claims{UserID: 123, Email: "test@example.com"}
+	if _, err := GenerateTokenWithClaims(cfg, second); err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	if first.ID == "" {
+		t.Error("Expected jti to be set")
+	}
+	if first.ID == second.ID {
+		t.Error("Expected every token to get a unique jti")
+	}
+	if first.Issuer != "test-issuer" {
+		t.Errorf("Expected issuer %q, got %q", "test-issuer", first.Issuer)
+	}
+}
+
+func TestJWTAuth_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.JWTConfig{
+		Secret:          "test-secret-key",
+		ExpirationHours: 24,
+		Issuer:          "test-issuer",
+	}
+
+	claims := &Claims{UserID: 123, Email: "test@example.com"}
+	token, err := GenerateTokenWithClaims(cfg, claims)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	validator := &revokedTokenValidator{revoked: map[string]bool{}}
+
+	r := gin.New()
+	r.Use(JWTAuth(cfg, validator))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d before revocation, got %d", http.StatusOK, w.Code)
+	}
+
+	validator.revoked[claims.ID] = true
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d after revocation, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Default cookie names and paths used when the config leaves them empty
+const (
+	DefaultAccessTokenCookie  = "access_token"
+	DefaultRefreshTokenCookie = "refresh_token"
+	DefaultRefreshCookiePath  = "/api/v1/auth"
+	DefaultCSRFCookie         = "csrf_token"
+)
+
+// CSRFHeader is the header browser clients echo the CSRF cookie in
+const CSRFHeader = "X-CSRF-Token"
+
+// OIDCStateCookie binds an OpenID Connect login to the browser that started it
+const (
+	OIDCStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+)
+
+// CookieSessionsEnabled reports whether tokens are delivered as cookies
+func CookieSessionsEnabled(cfg *config.JWTConfig) bool {
+	return cfg.Cookie.Enabled
+}
+
+// RefreshTokenTTL returns the lifetime of refresh tokens issued with the given config
+func RefreshTokenTTL(cfg *config.JWTConfig) time.Duration {
+	hours := cfg.RefreshExpirationHours
+	if hours <= 0 {
+		hours = 168 // default to 7 days
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// SetAuthCookies stores the token pair in HttpOnly cookies and issues a new
+// CSRF token in a cookie scripts can read
+func SetAuthCookies(c *gin.Context, cfg *config.JWTConfig, tokens *model.TokenPair) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	refreshTTL := RefreshTokenTTL(cfg)
+	setCookie(c, cfg, accessCookieName(cfg), tokens.AccessToken, "/", time.Duration(tokens.ExpiresIn)*time.Second, true)
+	setCookie(c, cfg, refreshCookieName(cfg), tokens.RefreshToken, refreshCookiePath(cfg), refreshTTL, true)
+	// The CSRF cookie lives as long as the refresh token, refreshing needs it too
+	setCookie(c, cfg, csrfCookieName(cfg), csrfToken, "/", refreshTTL, false)
+	return nil
+}
+
+// ClearAuthCookies expires the token and CSRF cookies
+func ClearAuthCookies(c *gin.Context, cfg *config.JWTConfig) {
+	setCookie(c, cfg, accessCookieName(cfg), "", "/", -1, true)
+	setCookie(c, cfg, refreshCookieName(cfg), "", refreshCookiePath(cfg), -1, true)
+	setCookie(c, cfg, csrfCookieName(cfg), "", "/", -1, false)
+}
+
+// RefreshTokenFromCookie returns the refresh token cookie, or an empty string
+// when there is none or cookie sessions are disabled
+func RefreshTokenFromCookie(c *gin.Context, cfg *config.JWTConfig) string {
+	if !CookieSessionsEnabled(cfg) {
+		return ""
+	}
+	token, _ := c.Cookie(refreshCookieName(cfg))
+	return token
+}
+
+// SetOIDCStateCookie stores the state of a started OpenID Connect login in an
+// HttpOnly cookie. It is always SameSite=Lax, a stricter mode would keep it
+// from the provider's redirect back, and set whether or not cookie sessions
+// are enabled.
+func SetOIDCStateCookie(c *gin.Context, cfg *config.JWTConfig, state string, maxAge time.Duration) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		Domain:   cfg.Cookie.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   !cfg.Cookie.Insecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearOIDCStateCookie expires the OpenID Connect state cookie
+func ClearOIDCStateCookie(c *gin.Context, cfg *config.JWTConfig) {
+	SetOIDCStateCookie(c, cfg, "", -1)
+}
+
+// OIDCStateFromCookie returns the state of the login started in this browser,
+// or an empty string when there is none
+func OIDCStateFromCookie(c *gin.Context) string {
+	state, _ := c.Cookie(OIDCStateCookie)
+	return state
+}
+
+// accessTokenFromCookie returns the access token cookie, or an empty string
+// when there is none or cookie sessions are disabled
+func accessTokenFromCookie(c *gin.Context, cfg *config.JWTConfig) string {
+	if !CookieSessionsEnabled(cfg) {
+		return ""
+	}
+	token, _ := c.Cookie(accessCookieName(cfg))
+	return token
+}
+
+// setCookie writes a cookie with the configured domain, Secure and SameSite
+// attributes. A negative maxAge deletes the cookie.
+func setCookie(c *gin.Context, cfg *config.JWTConfig, name, value, path string, maxAge time.Duration, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.Cookie.Domain,
+		Secure:   !cfg.Cookie.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(cfg.Cookie.SameSite),
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+func sameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func accessCookieName(cfg *config.JWTConfig) string {
+	if cfg.Cookie.AccessName != "" {
+		return cfg.Cookie.AccessName
+	}
+	return DefaultAccessTokenCookie
+}
+
+func refreshCookieName(cfg *config.JWTConfig) string {
+	if cfg.Cookie.RefreshName != "" {
+		return cfg.Cookie.RefreshName
+	}
+	return DefaultRefreshTokenCookie
+}
+
+func refreshCookiePath(cfg *config.JWTConfig) string {
+	if cfg.Cookie.RefreshPath != "" {
+		return cfg.Cookie.RefreshPath
+	}
+	return DefaultRefreshCookiePath
+}
+
+func csrfCookieName(cfg *config.JWTConfig) string {
+	if cfg.Cookie.CSRFName != "" {
+		return cfg.Cookie.CSRFName
+	}
+	return DefaultCSRFCookie
+}
+
+// newCSRFToken returns a random value for the CSRF cookie
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// CSRF protects cookie-authenticated requests with a double-submit token:
+// requests with an unsafe method that carry a token cookie must repeat the
+// CSRF cookie in the X-CSRF-Token header. Another site can make the browser
+// send the cookies, but cannot read the CSRF cookie to set the header.
+// Requests with an Authorization or X-API-Key header are not sent by browsers
+// on their own and pass unchecked.
+func CSRF(cfg *config.JWTConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CookieSessionsEnabled(cfg) || isSafeMethod(c.Request.Method) || !usesTokenCookie(c, cfg) {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(csrfCookieName(cfg))
+		header := c.GetHeader(CSRFHeader)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			response.ErrorFromAppError(c, apperrors.NewForbiddenError("invalid or missing csrf token"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// usesTokenCookie reports whether the request would be authenticated by a token cookie
+func usesTokenCookie(c *gin.Context, cfg *config.JWTConfig) bool {
+	if c.GetHeader("Authorization") != "" || c.GetHeader(APIKeyHeader) != "" {
+		return false
+	}
+	return accessTokenFromCookie(c, cfg) != "" || RefreshTokenFromCookie(c, cfg) != ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
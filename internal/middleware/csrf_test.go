@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+func newCookieTestConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		Secret:          "test-secret-key",
+		ExpirationHours: 24,
+		Issuer:          "test-issuer",
+		Cookie:          config.JWTCookieConfig{Enabled: true},
+	}
+}
+
+func TestSetAuthCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := newCookieTestConfig()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	tokens := &model.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}
+	if err := SetAuthCookies(c, cfg, tokens); err != nil {
+		t.Fatalf("SetAuthCookies failed: %v", err)
+	}
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	access := cookies[DefaultAccessTokenCookie]
+	if access == nil || access.Value != "access" || !access.HttpOnly || !access.Secure || access.SameSite != http.SameSiteLaxMode || access.MaxAge != 900 {
+		t.Errorf("unexpected access token cookie: %+v", access)
+	}
+	refresh := cookies[DefaultRefreshTokenCookie]
+	if refresh == nil || refresh.Value != "refresh" || !refresh.HttpOnly || refresh.Path != DefaultRefreshCookiePath {
+		t.Errorf("unexpected refresh token cookie: %+v", refresh)
+	}
+	csrf := cookies[DefaultCSRFCookie]
+	if csrf == nil || csrf.Value == "" || csrf.HttpOnly {
+		t.Errorf("CSRF cookie must be set and readable by scripts: %+v", csrf)
+	}
+}
+
+func TestJWTAuth_Cookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, err := GenerateToken(newCookieTestConfig(), 123, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    int
+	}{
+		{"cookie sessions enabled", true, http.StatusOK},
+		{"cookie sessions disabled", false, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCookieTestConfig()
+			cfg.Cookie.Enabled = tt.enabled
+
+			r := gin.New()
+			r.Use(JWTAuth(cfg))
+			r.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.AddCookie(&http.Cookie{Name: DefaultAccessTokenCookie, Value: token})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := newCookieTestConfig()
+
+	r := gin.New()
+	r.Use(CSRF(cfg))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.POST("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		cookies map[string]string
+		headers map[string]string
+		want    int
+	}{
+		{"safe method", "GET", map[string]string{DefaultAccessTokenCookie: "token"}, nil, http.StatusOK},
+		{"no session cookie", "POST", nil, nil, http.StatusOK},
+		{"matching token", "POST", map[string]string{DefaultAccessTokenCookie: "token", DefaultCSRFCookie: "csrf"}, map[string]string{CSRFHeader: "csrf"}, http.StatusOK},
+		{"missing header", "POST", map[string]string{DefaultAccessTokenCookie: "token", DefaultCSRFCookie: "csrf"}, nil, http.StatusForbidden},
+		{"wrong header", "POST", map[string]string{DefaultAccessTokenCookie: "token", DefaultCSRFCookie: "csrf"}, map[string]string{CSRFHeader: "other"}, http.StatusForbidden},
+		{"missing csrf cookie", "POST", map[string]string{DefaultRefreshTokenCookie: "token"}, map[string]string{CSRFHeader: ""}, http.StatusForbidden},
+		{"authorization header", "POST", map[string]string{DefaultAccessTokenCookie: "token"}, map[string]string{"Authorization": "Bearer token"}, http.StatusOK},
+		{"api key header", "POST", map[string]string{DefaultAccessTokenCookie: "token"}, map[string]string{APIKeyHeader: "key"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/test", nil)
+			for name, value := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		allowedOrigins  []string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"any origin", nil, "https://app.example.com", "*", ""},
+		{"allowed origin", []string{"https://app.example.com"}, "https://app.example.com", "https://app.example.com", "true"},
+		{"unknown origin", []string{"https://app.example.com"}, "https://evil.example.com", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORS(&config.CORSConfig{AllowedOrigins: tt.allowedOrigins}))
+			r.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Origin", tt.origin)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// DenyImpersonation creates a middleware that rejects requests made with an
+// impersonation token, for actions only the account owner may take.
+// It must be used after JWTAuth.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetClaimsFromContext(c)
+		if !exists {
+			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+			c.Abort()
+			return
+		}
+
+		if claims.IsImpersonated() {
+			response.ErrorFromAppError(c, apperrors.NewForbiddenError("not allowed while impersonating"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsImpersonated reports whether the token was issued to an admin acting as the user
+func (c *Claims) IsImpersonated() bool {
+	return c.Actor != nil
+}
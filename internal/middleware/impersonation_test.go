@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDenyImpersonation(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, DenyImpersonation())
+
+	tests := []struct {
+		name       string
+		actor      *Actor
+		wantStatus int
+	}{
+		{"own token", nil, http.StatusOK},
+		{"impersonation token", &Actor{UserID: 2, Email: "admin@example.com"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, &Claims{UserID: 1, Email: "test@example.com", Actor: tt.actor})
+			if code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, code)
+			}
+		})
+	}
+}
+
+func TestActorClaimRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+
+	var got *Claims
+	r := gin.New()
+	r.Use(JWTAuth(cfg))
+	r.GET("/test", func(c *gin.Context) {
+		got, _ = GetClaimsFromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	token, err := GenerateTokenWithClaims(cfg, &Claims{
+		UserID: 1,
+		Email:  "customer@example.com",
+		Actor:  &Actor{UserID: 2, Email: "admin@example.com", Generation: 3},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got == nil || !got.IsImpersonated() {
+		t.Fatal("Expected the claims to be marked as impersonated")
+	}
+	if got.UserID != 1 || got.Actor.UserID != 2 || got.Actor.Generation != 3 {
+		t.Errorf("Unexpected claims: user %d, actor %+v", got.UserID, got.Actor)
+	}
+}
@@ -0,0 +1,311 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the keys used to sign and verify tokens for one JWTConfig.
+// With HS256 the shared secret is used for both. With an asymmetric algorithm
+// tokens are signed with the private key selected by signing_key_id and verified
+// with any configured key, looked up by the kid header, which allows rotating
+// keys without invalidating tokens signed with the previous one.
+type KeySet struct {
+	method     jwt.SigningMethod
+	secret     []byte
+	signingKID string
+	signingKey crypto.PrivateKey
+	verifyKeys map[string]verificationKey
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// JWK represents a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS represents a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// keySets caches loaded key sets per config so PEM files are read once
+var keySets sync.Map
+
+// Keys returns the key set for the given config, loading it on first use
+func Keys(cfg *config.JWTConfig) (*KeySet, error) {
+	if ks, ok := keySets.Load(cfg); ok {
+		return ks.(*KeySet), nil
+	}
+
+	ks, err := LoadKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := keySets.LoadOrStore(cfg, ks)
+	return actual.(*KeySet), nil
+}
+
+// LoadKeySet builds a key set from the config, reading PEM files from disk
+func LoadKeySet(cfg *config.JWTConfig) (*KeySet, error) {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
+	if algorithm == jwt.SigningMethodHS256.Alg() {
+		return &KeySet{
+			method: jwt.SigningMethodHS256,
+			secret: []byte(cfg.Secret),
+		}, nil
+	}
+
+	ks := &KeySet{
+		method:     jwt.GetSigningMethod(algorithm),
+		signingKID: cfg.SigningKeyID,
+		verifyKeys: make(map[string]verificationKey),
+	}
+	if ks.method == nil {
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", algorithm)
+	}
+	if ks.signingKID == "" {
+		return nil, errors.New("jwt signing_key_id is required for asymmetric algorithms")
+	}
+
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.ID == "" {
+			return nil, errors.New("jwt key id is required")
+		}
+		if _, exists := ks.verifyKeys[keyCfg.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", keyCfg.ID)
+		}
+
+		privateKey, publicKey, err := loadKeyPair(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %q: %w", keyCfg.ID, err)
+		}
+
+		method, err := signingMethodForKey(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %q: %w", keyCfg.ID, err)
+		}
+		ks.verifyKeys[keyCfg.ID] = verificationKey{method: method, key: publicKey}
+
+		if keyCfg.ID == ks.signingKID {
+			if privateKey == nil {
+				return nil, fmt.Errorf("jwt signing key %q has no private key", keyCfg.ID)
+			}
+			if method.Alg() != ks.method.Alg() {
+				return nil, fmt.Errorf("jwt signing key %q is a %s key, expected %s", keyCfg.ID, method.Alg(), ks.method.Alg())
+			}
+			ks.signingKey = privateKey
+		}
+	}
+
+	if ks.signingKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q is not configured", ks.signingKID)
+	}
+
+	return ks, nil
+}
+
+// Sign signs the claims with the current signing key and stamps its kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.secret != nil {
+		return token.SignedString(ks.secret)
+	}
+
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc returns the key to verify a token with, based on its kid header
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.secret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.key, nil
+}
+
+// ValidMethods returns the algorithms accepted when verifying tokens
+func (ks *KeySet) ValidMethods() []string {
+	if ks.secret != nil {
+		return []string{ks.method.Alg()}
+	}
+
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.verifyKeys {
+		if !seen[key.method.Alg()] {
+			seen[key.method.Alg()] = true
+			methods = append(methods, key.method.Alg())
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// JWKS returns the public verification keys. It is empty for HS256,
+// since the shared secret must never be published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	kids := make([]string, 0, len(ks.verifyKeys))
+	for kid := range ks.verifyKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key := ks.verifyKeys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+
+		switch pub := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// loadKeyPair reads the private key file if set, otherwise only the public key file
+func loadKeyPair(cfg config.JWTKeyConfig) (crypto.PrivateKey, crypto.PublicKey, error) {
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		privateKey, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, errors.New("unsupported private key type")
+		}
+		return privateKey, signer.Public(), nil
+	}
+
+	if cfg.PublicKeyFile == "" {
+		return nil, nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	block, err := readPEM(cfg.PublicKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return nil, publicKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM block", path)
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return key, nil
+	}
+}
+
+// signingMethodForKey derives the JWT algorithm from the key type
+func signingMethodForKey(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, errors.New("unsupported elliptic curve")
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
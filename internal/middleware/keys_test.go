@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeyPair writes the private key as PKCS#8 and the public key as PKIX PEM files
+func writeKeyPair(t *testing.T, dir, name string, key crypto.Signer) (string, string) {
+	t.Helper()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestKeySet_AsymmetricAlgorithms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		algorithm string
+		kty       string
+		key       crypto.Signer
+	}{
+		{"RS256", "RSA", rsaKey},
+		{"ES256", "EC", ecKey},
+		{"EdDSA", "OKP", edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			privatePath, _ := writeKeyPair(t, dir, tt.algorithm, tt.key)
+			cfg := &config.JWTConfig{
+				Algorithm:    tt.algorithm,
+				SigningKeyID: "key-1",
+				Keys:         []config.JWTKeyConfig{{ID: "key-1", PrivateKeyFile: privatePath}},
+				Issuer:       "test-issuer",
+			}
+
+			token, err := GenerateToken(cfg, 123, "test@example.com")
+			if err != nil {
+				t.Fatalf("GenerateToken failed: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatalf("failed to parse token: %v", err)
+			}
+			if parsed.Header["alg"] != tt.algorithm {
+				t.Errorf("Expected alg %s, got %v", tt.algorithm, parsed.Header["alg"])
+			}
+			if parsed.Header["kid"] != "key-1" {
+				t.Errorf("Expected kid key-1, got %v", parsed.Header["kid"])
+			}
+
+			r := gin.New()
+			r.Use(JWTAuth(cfg))
+			r.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			keys, err := Keys(cfg)
+			if err != nil {
+				t.Fatalf("Keys failed: %v", err)
+			}
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != tt.kty || jwks.Keys[0].Alg != tt.algorithm {
+				t.Errorf("Unexpected JWKS: %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	oldPrivate, oldPublic := writeKeyPair(t, dir, "old", oldKey)
+	newPrivate, _ := writeKeyPair(t, dir, "new", newKey)
+
+	before := &config.JWTConfig{
+		Algorithm:    "ES256",
+		SigningKeyID: "old",
+		Keys:         []config.JWTKeyConfig{{ID: "old", PrivateKeyFile: oldPrivate}},
+	}
+	after := &config.JWTConfig{
+		Algorithm:    "ES256",
+		SigningKeyID: "new",
+		Keys: []config.JWTKeyConfig{
+			{ID: "new", PrivateKeyFile: newPrivate},
+			{ID: "old", PublicKeyFile: oldPublic},
+		},
+	}
+
+	oldToken, err := GenerateToken(before, 1, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	keys, err := Keys(after)
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+
+	if _, err := jwt.ParseWithClaims(oldToken, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods())); err != nil {
+		t.Errorf("Expected token signed with the previous key to verify, got %v", err)
+	}
+	if len(keys.JWKS().Keys) != 2 {
+		t.Errorf("Expected 2 keys in JWKS, got %d", len(keys.JWKS().Keys))
+	}
+
+	// Tokens signed with an unknown key must be rejected
+	retired := &config.JWTConfig{
+		Algorithm:    "ES256",
+		SigningKeyID: "new",
+		Keys:         []config.JWTKeyConfig{{ID: "new", PrivateKeyFile: newPrivate}},
+	}
+	retiredKeys, err := Keys(retired)
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if _, err := jwt.ParseWithClaims(oldToken, &Claims{}, retiredKeys.Keyfunc, jwt.WithValidMethods(retiredKeys.ValidMethods())); err == nil {
+		t.Error("Expected token signed with a removed key to be rejected")
+	}
+}
+
+func TestKeySet_RejectsHMACWhenAsymmetric(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privatePath, _ := writeKeyPair(t, dir, "key", key)
+
+	cfg := &config.JWTConfig{
+		Secret:       "test-secret-key",
+		Algorithm:    "ES256",
+		SigningKeyID: "key",
+		Keys:         []config.JWTKeyConfig{{ID: "key", PrivateKeyFile: privatePath}},
+	}
+
+	hmacToken, err := GenerateToken(&config.JWTConfig{Secret: "test-secret-key"}, 1, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	keys, err := Keys(cfg)
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if _, err := jwt.ParseWithClaims(hmacToken, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods())); err == nil {
+		t.Error("Expected HS256 token to be rejected when an asymmetric algorithm is configured")
+	}
+}
+
+func TestLoadKeySet_MissingSigningKey(t *testing.T) {
+	cfg := &config.JWTConfig{
+		Algorithm:    "RS256",
+		SigningKeyID: "missing",
+	}
+
+	if _, err := LoadKeySet(cfg); err == nil {
+		t.Error("Expected an error when the signing key is not configured")
+	}
+}
@@ -20,7 +20,7 @@ func Logger() gin.HandlerFunc {
 		end := time.Now()
 		latency := end.Sub(start)
 
-		logger.Info("HTTP Request",
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
@@ -29,6 +29,28 @@ func Logger() gin.HandlerFunc {
 			zap.Duration("latency", latency),
 			zap.String("user-agent", c.Request.UserAgent()),
 			zap.String("error", c.Errors.ByType(gin.ErrorTypePrivate).String()),
-		)
+		}
+
+		if requestID, ok := GetRequestIDFromContext(c); ok {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+
+		if clientID, ok := GetClientIDFromContext(c); ok {
+			fields = append(fields, zap.String("client_id", clientID))
+		}
+
+		// Requests an admin makes on behalf of a user are marked so they stand out in the logs
+		if claims, ok := GetClaimsFromContext(c); ok && claims.IsImpersonated() {
+			fields = append(fields,
+				zap.Bool("impersonated", true),
+				zap.Uint("user_id", claims.UserID),
+				zap.Uint("impersonator_id", claims.Actor.UserID),
+				zap.String("impersonator_email", claims.Actor.Email),
+			)
+			logger.Warn("HTTP Request (impersonated)", fields...)
+			return
+		}
+
+		logger.Info("HTTP Request", fields...)
 	}
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireMFA creates a middleware that allows the request only if the access
+// token was issued after multi-factor authentication.
+// It must be used after JWTAuth.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetClaimsFromContext(c)
+		if !exists {
+			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+			c.Abort()
+			return
+		}
+
+		if !claims.HasMFA() {
+			response.ErrorFromAppError(c, apperrors.NewForbiddenError("multi-factor authentication required"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasMFA reports whether the token was issued after multi-factor authentication
+func (c *Claims) HasMFA() bool {
+	return contains(c.AMR, model.AMRMFA)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+)
+
+func TestRequireMFA(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, RequireMFA())
+
+	tests := []struct {
+		name       string
+		amr        []string
+		wantStatus int
+	}{
+		{"password only", []string{"pwd"}, http.StatusForbidden},
+		{"no amr", nil, http.StatusForbidden},
+		{"password and otp", []string{"pwd", "otp", "mfa"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, &Claims{UserID: 1, Email: "test@example.com", AMR: tt.amr})
+			if code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, code)
+			}
+		})
+	}
+}
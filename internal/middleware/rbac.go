@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole creates a middleware that allows the request only if the
+// authenticated user has at least one of the given roles.
+// It must be used after JWTAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetClaimsFromContext(c)
+		if !exists {
+			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		response.ErrorFromAppError(c, apperrors.NewForbiddenError("insufficient role"))
+		c.Abort()
+	}
+}
+
+// RequirePermission creates a middleware that allows the request only if the
+// authenticated user has all of the given permissions.
+// It must be used after JWTAuth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetClaimsFromContext(c)
+		if !exists {
+			response.ErrorFromAppError(c, apperrors.NewUnauthorizedError("user not authenticated"))
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				response.ErrorFromAppError(c, apperrors.NewForbiddenError("missing permission: "+permission))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// HasRole reports whether the claims include the given role
+func (c *Claims) HasRole(role string) bool {
+	return contains(c.Roles, role)
+}
+
+// HasPermission reports whether the claims include the given permission.
+// OAuth clients hold the permissions named by their scopes.
+func (c *Claims) HasPermission(permission string) bool {
+	if c.IsClient() {
+		return c.HasScope(permission)
+	}
+	return contains(c.Permissions, permission)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func newRBACTestRouter(t *testing.T, cfg *config.JWTConfig, guard gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(JWTAuth(cfg))
+	r.GET("/test", guard, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r
+}
+
+func doRBACRequest(t *testing.T, r *gin.Engine, cfg *config.JWTConfig, claims *Claims) int {
+	t.Helper()
+
+	token, err := GenerateTokenWithClaims(cfg, claims)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, RequireRole("admin", "support"))
+
+	tests := []struct {
+		name     string
+		roles    []string
+		expected int
+	}{
+		{"matching role", []string{"user", "admin"}, http.StatusOK},
+		{"any of the roles", []string{"support"}, http.StatusOK},
+		{"missing role", []string{"user"}, http.StatusForbidden},
+		{"no roles", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, &Claims{UserID: 1, Roles: tt.roles})
+			if code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, RequirePermission("products:write", "products:read"))
+
+	tests := []struct {
+		name        string
+		permissions []string
+		expected    int
+	}{
+		{"all permissions", []string{"products:read", "products:write"}, http.StatusOK},
+		{"partial permissions", []string{"products:write"}, http.StatusForbidden},
+		{"no permissions", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, &Claims{UserID: 1, Permissions: tt.permissions})
+			if code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestRequireRole_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/test", RequireRole("admin"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -18,6 +18,7 @@ func Recovery() gin.HandlerFunc {
 					zap.Any("error", err),
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
+					zap.String("request_id", logger.RequestID(c.Request.Context())),
 				)
 
 				c.JSON(http.StatusInternalServerError, response.Response{
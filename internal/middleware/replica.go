@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadPrimaryCookie marks a client that changed data in the last seconds, see ReadYourWrites
+const ReadPrimaryCookie = "read_primary"
+
+// ReadYourWrites makes requests that change data read from the primary
+// database, as services often read a record before updating it. The client
+// then gets a short-lived cookie that keeps its next requests on the primary
+// too, so it sees its own changes while the read replicas catch up. Clients
+// that do not keep cookies may read their changes late. Without configured
+// replicas all reads use the primary and the middleware does nothing.
+func ReadYourWrites(cfg *config.DatabaseConfig) gin.HandlerFunc {
+	window := cfg.ReadYourWrites
+	if window <= 0 {
+		window = 5 // default to 5 seconds
+	}
+
+	return func(c *gin.Context) {
+		if len(cfg.Replicas) == 0 {
+			c.Next()
+			return
+		}
+
+		mutation := !isSafeMethod(c.Request.Method)
+		if mutation {
+			// Set up front, the response is written before the handler returns
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     ReadPrimaryCookie,
+				Value:    "1",
+				Path:     "/",
+				MaxAge:   window,
+				Secure:   c.Request.TLS != nil,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		if _, err := c.Cookie(ReadPrimaryCookie); mutation || err == nil {
+			c.Request = c.Request.WithContext(database.WithPrimary(c.Request.Context()))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withReplicas := &config.DatabaseConfig{Replicas: []config.ReplicaConfig{{Host: "replica"}}}
+
+	tests := []struct {
+		name          string
+		cfg           *config.DatabaseConfig
+		method        string
+		cookie        bool
+		expectPrimary bool
+		expectCookie  bool
+	}{
+		{"read", withReplicas, http.MethodGet, false, false, false},
+		{"change", withReplicas, http.MethodPost, false, true, true},
+		{"read after change", withReplicas, http.MethodGet, true, true, false},
+		{"no replicas", &config.DatabaseConfig{}, http.MethodPost, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ReadYourWrites(tt.cfg))
+			var primary bool
+			r.Handle(tt.method, "/", func(c *gin.Context) {
+				primary = database.UsesPrimary(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: ReadPrimaryCookie, Value: "1"})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if primary != tt.expectPrimary {
+				t.Errorf("Expected primary reads %v, got %v", tt.expectPrimary, primary)
+			}
+			var cookie *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == ReadPrimaryCookie {
+					cookie = c
+				}
+			}
+			if (cookie != nil) != tt.expectCookie {
+				t.Errorf("Expected cookie %v, got %+v", tt.expectCookie, cookie)
+			}
+			if cookie != nil && cookie.MaxAge != 5 {
+				t.Errorf("Expected the default window of 5 seconds, got %d", cookie.MaxAge)
+			}
+		})
+	}
+}
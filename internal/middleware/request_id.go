@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the request IDs taken from clients or proxies to what
+// can be logged safely
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, the X-Request-ID header of the client
+// or proxy when it has a valid one and a random UUID otherwise. The ID is
+// returned in the response header and carried by the request context, where
+// the request and SQL logs pick it up.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// GetRequestIDFromContext returns the ID set by RequestID
+func GetRequestIDFromContext(c *gin.Context) (string, bool) {
+	requestID, exists := c.Get("request_id")
+	if !exists {
+		return "", false
+	}
+	id, ok := requestID.(string)
+	return id, ok
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string // Empty when a new ID is expected
+	}{
+		{"generated", "", ""},
+		{"from proxy", "abc-123_x.y", "abc-123_x.y"},
+		{"invalid", "bad id\nforged log line", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestID())
+			var fromGin, fromContext string
+			r.GET("/", func(c *gin.Context) {
+				fromGin, _ = GetRequestIDFromContext(c)
+				fromContext = logger.RequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.expected != "" && id != tt.expected {
+				t.Errorf("Expected request ID %q, got %q", tt.expected, id)
+			}
+			if tt.expected == "" {
+				if _, err := uuid.Parse(id); err != nil {
+					t.Errorf("Expected a generated UUID, got %q", id)
+				}
+			}
+			if fromGin != id || fromContext != id {
+				t.Errorf("Expected %q in the gin and request context, got %q and %q", id, fromGin, fromContext)
+			}
+		})
+	}
+}
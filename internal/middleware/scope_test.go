@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScope(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, RequireScope("reports:read", "reports:write"))
+
+	tests := []struct {
+		name     string
+		claims   *Claims
+		expected int
+	}{
+		{"all scopes", &Claims{ClientID: "svc_1", Scope: "reports:write reports:read"}, http.StatusOK},
+		{"partial scopes", &Claims{ClientID: "svc_1", Scope: "reports:read"}, http.StatusForbidden},
+		{"user token", &Claims{UserID: 1, Permissions: []string{"reports:read", "reports:write"}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, tt.claims)
+			if code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestRequirePermission_ClientScopes(t *testing.T) {
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+	r := newRBACTestRouter(t, cfg, RequirePermission("products:write"))
+
+	tests := []struct {
+		name     string
+		claims   *Claims
+		expected int
+	}{
+		{"scope grants permission", &Claims{ClientID: "svc_1", Scope: "products:write"}, http.StatusOK},
+		{"missing scope", &Claims{ClientID: "svc_1", Scope: "products:read"}, http.StatusForbidden},
+		{"permissions ignored for clients", &Claims{ClientID: "svc_1", Permissions: []string{"products:write"}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := doRBACRequest(t, r, cfg, tt.claims)
+			if code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_ClientPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.JWTConfig{Secret: "test-secret-key", Issuer: "test-issuer"}
+
+	token, err := GenerateTokenWithClaims(cfg, &Claims{ClientID: "svc_1", Scope: "reports:read"})
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClaims failed: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(JWTAuth(cfg))
+	r.GET("/test", func(c *gin.Context) {
+		_, hasUser := GetUserIDFromContext(c)
+		clientID, _ := GetClientIDFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"has_user": hasUser, "client_id": clientID})
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); body != `{"client_id":"svc_1","has_user":false}` {
+		t.Errorf("client tokens must not populate the user, got %s", body)
+	}
+}
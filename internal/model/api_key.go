@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+)
+
+// APIKey is a long-lived credential for machine clients. It acts on behalf of
+// its owner, limited to its scopes. Only the SHA-256 hash of the key is stored;
+// the prefix is kept in plain text to look the key up and to tell keys apart.
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:char(64);not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:text" json:"scopes"` // Permissions the key may use, a subset of the owner's
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"omitempty"`
+	ExpiresAt *time.Time `json:"expires_at" binding:"omitempty"`
+}
+
+// UpdateAPIKeyRequest represents the request body for updating an API key
+type UpdateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"omitempty,max=100"`
+	Scopes    []string   `json:"scopes" binding:"omitempty"` // Replaces the scopes when set
+	ExpiresAt *time.Time `json:"expires_at" binding:"omitempty"`
+}
+
+// CreateAPIKeyResponse holds a new API key together with its secret, which is shown only once
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
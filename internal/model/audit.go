@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditActionImpersonate = "impersonate"
+)
+
+// AuditLog records an administrative action that has to be traceable later
+type AuditLog struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	Action       string    `gorm:"type:varchar(64);index;not null" json:"action"`
+	ActorID      uint      `gorm:"index;not null" json:"actor_id"`        // User who performed the action
+	TargetUserID uint      `gorm:"index" json:"target_user_id,omitempty"` // User the action was performed on, if any
+	Reason       string    `gorm:"type:varchar(500)" json:"reason,omitempty"`
+	IP           string    `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent    string    `gorm:"type:varchar(512)" json:"user_agent"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// ImpersonateRequest represents the optional request body for impersonating a user
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=500"` // Why the user is impersonated, e.g. a support ticket
+}
+
+// ImpersonationToken is a short-lived access token that acts as another user.
+// It cannot be refreshed.
+type ImpersonationToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"` // Lifetime in seconds
+	UserID      uint   `json:"user_id"`    // Impersonated user
+	ActorID     uint   `json:"actor_id"`   // Admin the token was issued to
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// Identity links a user to an account at an external OpenID Connect provider.
+// The account is identified by the provider's issuer URL and its subject.
+type Identity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Issuer    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_identities_issuer_subject" json:"issuer"`
+	Subject   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_identities_issuer_subject" json:"subject"`
+	Email     string    `gorm:"type:varchar(100)" json:"email"` // Email reported by the provider at the last login
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Identity model
+func (Identity) TableName() string {
+	return "identities"
+}
@@ -0,0 +1,15 @@
+package model
+
+// Lockout subjects
+const (
+	LockoutTypeEmail = "email"
+	LockoutTypeIP    = "ip"
+)
+
+// Lockout describes an active login lockout
+type Lockout struct {
+	Type       string `json:"type"`        // email or ip
+	Key        string `json:"key"`         // The locked email address or client IP
+	Failures   int64  `json:"failures"`    // Failed attempts counted when the lockout started
+	RetryAfter int64  `json:"retry_after"` // Seconds until the lockout ends
+}
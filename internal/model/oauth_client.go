@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+)
+
+// GrantTypeClientCredentials is the OAuth2 grant for service-to-service calls
+const GrantTypeClientCredentials = "client_credentials"
+
+// OAuth2 error codes returned by the token endpoint (RFC 6749, section 5.2)
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorServerError          = "server_error"
+)
+
+// OAuthClient is a service registered to obtain tokens with the client
+// credentials grant. Its tokens represent the service itself, not a user.
+// Only the SHA-256 hash of the secret is stored.
+type OAuthClient struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	ClientID   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"client_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	SecretHash string     `gorm:"type:char(64);not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:text" json:"scopes"` // Scopes the client may request
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for OAuthClient model
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// CreateOAuthClientRequest represents the request body for registering a client
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// CreateOAuthClientResponse holds a new client together with its secret, which is shown only once
+type CreateOAuthClientResponse struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret"`
+}
+
+// ClientTokenResponse is the token endpoint response (RFC 6749, section 5.1)
+type ClientTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"` // Access token lifetime in seconds
+	Scope       string `json:"scope"`      // Granted scopes, space separated
+}
+
+// OAuthErrorResponse is the token endpoint error response (RFC 6749, section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
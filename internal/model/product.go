@@ -2,17 +2,20 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// Product represents a product in the system
+// Product represents a product in the system. Deleted products are soft deleted.
 type Product struct {
-	ID          uint      `gorm:"primarykey" json:"id"`
-	Name        string    `gorm:"type:varchar(200);not null" json:"name" binding:"required"`
-	Description string    `gorm:"type:text" json:"description"`
-	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price" binding:"required,gt=0"`
-	Stock       int       `gorm:"type:int;not null;default:0" json:"stock" binding:"omitempty,gte=0"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Name        string         `gorm:"type:varchar(200);not null" json:"name" binding:"required"`
+	Description string         `gorm:"type:text" json:"description"`
+	Price       float64        `gorm:"type:decimal(10,2);not null" json:"price" binding:"required,gt=0"`
+	Stock       int            `gorm:"type:int;not null;default:0" json:"stock" binding:"omitempty,gte=0"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // Set while the product is in the trash, see the purge job
 }
 
 // TableName specifies the table name for Product model
@@ -35,3 +38,46 @@ type UpdateProductRequest struct {
 	Price       float64 `json:"price" binding:"omitempty,gt=0"`
 	Stock       int     `json:"stock" binding:"omitempty,gte=0"`
 }
+
+// ProductFilter holds the criteria products are listed by, empty fields match every product
+type ProductFilter struct {
+	Q             string     `form:"q"`                                   // Name or description contains the text
+	Name          string     `form:"name"`                                // Name contains the text
+	PriceMin      *float64   `form:"price_min" binding:"omitempty,gte=0"` // Inclusive
+	PriceMax      *float64   `form:"price_max" binding:"omitempty,gte=0"` // Inclusive
+	StockLT       *int       `form:"stock_lt" binding:"omitempty,gte=0"`  // Stock below the value, e.g. 5 for products running low
+	CreatedAfter  *time.Time `form:"created_after"`                       // RFC 3339, inclusive
+	CreatedBefore *time.Time `form:"created_before"`                      // RFC 3339, exclusive
+}
+
+// ProductSortFields are the fields products can be sorted by
+var ProductSortFields = []string{"id", "name", "price", "stock", "created_at", "updated_at"}
+
+// SortValue returns the value of a field in ProductSortFields
+func (p *Product) SortValue(field string) any {
+	switch field {
+	case "id":
+		return p.ID
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price
+	case "stock":
+		return p.Stock
+	case "created_at":
+		return p.CreatedAt
+	case "updated_at":
+		return p.UpdatedAt
+	}
+	return nil
+}
+
+// ListProductsRequest represents the query parameters for listing products
+type ListProductsRequest struct {
+	ProductFilter
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Sort     string `form:"sort"`   // Comma separated ProductSortFields, prefixed with - for descending, e.g. -price,name
+	Cursor   string `form:"cursor"` // next_cursor or prev_cursor of a previous page, replaces page
+	Count    *bool  `form:"count"`  // Return the total, by default only without a cursor
+}
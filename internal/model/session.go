@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ClientInfo describes the client a request came from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// Session represents one login of a user, covering every token refreshed from it
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"` // Address of the most recent login or refresh
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // Whether the request listing the sessions was made from this session
+}
@@ -0,0 +1,42 @@
+package model
+
+// TokenPair represents a short-lived access token and the refresh token used to renew it
+// With cookie sessions enabled both tokens travel in cookies and are omitted from response bodies
+type TokenPair struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// RefreshTokenRequest represents the request body for refreshing a token pair.
+// Browser clients on cookie sessions send the refresh token cookie instead.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"omitempty"`
+}
+
+// LogoutRequest represents the optional request body for logging out
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"omitempty"`
+}
+
+// ForgotPasswordRequest represents the request body for requesting a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request body for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
+}
+
+// VerifyEmailRequest represents the request body for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest represents the request body for requesting a new verification link
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
@@ -2,17 +2,27 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// User represents a user in the system
+// User represents a user in the system. Deleted users are soft deleted and
+// keep their email reserved until they are purged.
 type User struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	Name      string    `gorm:"type:varchar(100);not null" json:"name" binding:"required"`
-	Email     string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"email" binding:"required,email"`
-	Password  string    `gorm:"type:varchar(255);not null" json:"password,omitempty" binding:"required,min=6"`
-	Age       int       `gorm:"type:int" json:"age" binding:"omitempty,gte=0,lte=150"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint           `gorm:"primarykey" json:"id"`
+	Name            string         `gorm:"type:varchar(100);not null" json:"name" binding:"required"`
+	Email           string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"email" binding:"required,email"`
+	Password        string         `gorm:"type:varchar(255);not null" json:"password,omitempty" binding:"required,min=6"`
+	Age             int            `gorm:"type:int" json:"age" binding:"omitempty,gte=0,lte=150"`
+	Disabled        bool           `gorm:"not null;default:false" json:"disabled"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	PendingEmail    string         `gorm:"type:varchar(100)" json:"pending_email,omitempty"` // New email waiting for confirmation, Email stays in use until then
+	MFAEnabled      bool           `gorm:"not null;default:false" json:"mfa_enabled"`
+	MFASecret       string         `gorm:"type:varchar(64)" json:"-"` // Base32 TOTP secret, set once enrollment is confirmed
+	Roles           []Role         `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"` // Set while the user is in the trash, see the purge job
 }
 
 // TableName specifies the table name for User model
@@ -24,7 +34,7 @@ func (User) TableName() string {
 type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
 	Age      int    `json:"age" binding:"omitempty,gte=0,lte=150"`
 }
 
@@ -32,6 +42,68 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
 	Name     string `json:"name" binding:"omitempty"`
 	Email    string `json:"email" binding:"omitempty,email"`
-	Password string `json:"password" binding:"omitempty,min=6"`
+	Password string `json:"password" binding:"omitempty"` // Checked against the password policy
 	Age      int    `json:"age" binding:"omitempty,gte=0,lte=150"`
+	Disabled *bool  `json:"disabled" binding:"omitempty"`
+}
+
+// UserFilter holds the criteria users are listed by, empty fields match every user
+type UserFilter struct {
+	Q             string     `form:"q"`                                 // Name or email contains the text
+	Name          string     `form:"name"`                              // Name contains the text
+	Email         string     `form:"email" binding:"omitempty,email"`   // Exact email
+	AgeMin        *int       `form:"age_min" binding:"omitempty,gte=0"` // Inclusive
+	AgeMax        *int       `form:"age_max" binding:"omitempty,gte=0"` // Inclusive
+	CreatedAfter  *time.Time `form:"created_after"`                     // RFC 3339, inclusive
+	CreatedBefore *time.Time `form:"created_before"`                    // RFC 3339, exclusive
+}
+
+// UserSortFields are the fields users can be sorted by
+var UserSortFields = []string{"id", "name", "email", "age", "created_at", "updated_at"}
+
+// SortValue returns the value of a field in UserSortFields
+func (u *User) SortValue(field string) any {
+	switch field {
+	case "id":
+		return u.ID
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "age":
+		return u.Age
+	case "created_at":
+		return u.CreatedAt
+	case "updated_at":
+		return u.UpdatedAt
+	}
+	return nil
+}
+
+// ListUsersRequest represents the query parameters for listing users
+type ListUsersRequest struct {
+	UserFilter
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Sort     string `form:"sort"`   // Comma separated UserSortFields, prefixed with - for descending, e.g. -created_at,name
+	Cursor   string `form:"cursor"` // next_cursor or prev_cursor of a previous page, replaces page
+	Count    *bool  `form:"count"`  // Return the total, by default only without a cursor
+}
+
+// UpdateProfileRequest represents the request body for updating the current user's profile
+type UpdateProfileRequest struct {
+	Name  string `json:"name" binding:"omitempty"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Age   int    `json:"age" binding:"omitempty,gte=0,lte=150"`
+}
+
+// ChangePasswordRequest represents the request body for changing the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest represents the request body for deleting the current user's account
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
 }
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository handles database operations for API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByID(ctx context.Context, userID, id uint) (*model.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	Update(ctx context.Context, key *model.APIKey) error
+	Delete(ctx context.Context, userID, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create creates a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return conn(ctx, r.db).Create(key).Error
+}
+
+// GetByID retrieves an API key by ID, scoped to its owner
+func (r *apiKeyRepository) GetByID(ctx context.Context, userID, id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := conn(ctx, r.db).Where("user_id = ?", userID).First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByPrefix retrieves an API key by its public prefix
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := conn(ctx, r.db).Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser retrieves all API keys of a user
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Update updates an API key
+func (r *apiKeyRepository) Update(ctx context.Context, key *model.APIKey) error {
+	return conn(ctx, r.db).Save(key).Error
+}
+
+// Delete deletes an API key, scoped to its owner
+func (r *apiKeyRepository) Delete(ctx context.Context, userID, id uint) error {
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&model.APIKey{}, id).Error
+}
+
+// TouchLastUsed records when the key was last used without touching updated_at
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return conn(ctx, r.db).Model(&model.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
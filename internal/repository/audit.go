@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// AuditRepository handles database operations for audit logs
+type AuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditLog) error
+	List(ctx context.Context, offset, limit int) ([]*model.AuditLog, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Create stores an audit log entry
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	return conn(ctx, r.db).Create(entry).Error
+}
+
+// List retrieves audit log entries with pagination, newest first
+func (r *auditRepository) List(ctx context.Context, offset, limit int) ([]*model.AuditLog, error) {
+	var entries []*model.AuditLog
+	err := conn(ctx, r.db).Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Count returns the total number of audit log entries
+func (r *auditRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&model.AuditLog{}).Count(&count).Error
+	return count, err
+}
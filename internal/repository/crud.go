@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
+	"gorm.io/gorm"
+)
+
+// Repository is the set of database operations every resource supports
+type Repository[T any, ID comparable] interface {
+	Create(ctx context.Context, entity *T) error
+	GetByID(ctx context.Context, id ID) (*T, error)
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, id ID) error
+}
+
+// TrashRepository is implemented by repositories of soft deleted resources,
+// whose models have a gorm.DeletedAt field named DeletedAt
+type TrashRepository[T any, ID comparable] interface {
+	ListDeleted(ctx context.Context, offset, limit int) ([]*T, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	Restore(ctx context.Context, id ID) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Scope narrows a query down, e.g. to the rows matching a list filter
+type Scope func(db *gorm.DB) *gorm.DB
+
+// CRUD implements Repository and TrashRepository for a model whose primary
+// key is the id column. Resource repositories embed it and add their own
+// queries through DB, e.g. a lookup by email:
+//
+//	type userRepository struct {
+//		*CRUD[model.User, uint]
+//	}
+//
+//	func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+//		return r.First(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("email = ?", email) })
+//	}
+//
+// GetByID, Find and Count read from a read replica unless they run in a
+// transaction or with database.WithPrimary; everything else uses the primary.
+// A replica may lag behind, so callers that write the loaded entity back or
+// base a security decision on it, e.g. the Disabled flag of a user, load it
+// with database.WithPrimary instead of relying on the request's HTTP method.
+type CRUD[T any, ID comparable] struct {
+	db *gorm.DB
+}
+
+// NewCRUD creates the generic operations for the model T
+func NewCRUD[T any, ID comparable](db *gorm.DB) *CRUD[T, ID] {
+	return &CRUD[T, ID]{db: db}
+}
+
+// DB returns the database handle for queries of the embedding repository,
+// the ambient transaction when ctx carries one, see TxManager
+func (r *CRUD[T, ID]) DB(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db)
+}
+
+// Create creates a new entity
+func (r *CRUD[T, ID]) Create(ctx context.Context, entity *T) error {
+	return r.DB(ctx).Create(entity).Error
+}
+
+// GetByID retrieves an entity by ID, from a read replica outside transactions
+func (r *CRUD[T, ID]) GetByID(ctx context.Context, id ID) (*T, error) {
+	var entity T
+	err := reader(ctx, r.db).Where("id = ?", id).First(&entity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// First retrieves the first entity in the scope by primary key. It returns
+// gorm.ErrRecordNotFound when the scope is empty.
+func (r *CRUD[T, ID]) First(ctx context.Context, scope Scope) (*T, error) {
+	var entity T
+	err := scope(r.DB(ctx)).First(&entity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Update saves all fields of an entity
+func (r *CRUD[T, ID]) Update(ctx context.Context, entity *T) error {
+	return r.DB(ctx).Save(entity).Error
+}
+
+// Delete deletes an entity by ID, soft deleting models with a DeletedAt field
+func (r *CRUD[T, ID]) Delete(ctx context.Context, id ID) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(new(T)).Error
+}
+
+// Find retrieves the entities in the scope on the page, from a read replica
+// outside transactions. It fetches one row more than the page size, see query.Page.
+func (r *CRUD[T, ID]) Find(ctx context.Context, scope Scope, page query.Page) ([]*T, error) {
+	var entities []*T
+	err := paginate(scope(reader(ctx, r.db)), page).Find(&entities).Error
+	if err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// Count returns the number of entities in the scope, from a read replica outside transactions
+func (r *CRUD[T, ID]) Count(ctx context.Context, scope Scope) (int64, error) {
+	var count int64
+	err := scope(reader(ctx, r.db).Model(new(T))).Count(&count).Error
+	return count, err
+}
+
+// ListDeleted retrieves entities in the trash with pagination, most recently deleted first
+func (r *CRUD[T, ID]) ListDeleted(ctx context.Context, offset, limit int) ([]*T, error) {
+	var entities []*T
+	err := r.DB(ctx).Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&entities).Error
+	if err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// CountDeleted returns the number of entities in the trash
+func (r *CRUD[T, ID]) CountDeleted(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Unscoped().Model(new(T)).Where("deleted_at IS NOT NULL").Count(&count).Error
+	return count, err
+}
+
+// Restore takes an entity out of the trash. It returns gorm.ErrRecordNotFound
+// when no deleted entity has the ID.
+func (r *CRUD[T, ID]) Restore(ctx context.Context, id ID) error {
+	result := r.DB(ctx).Unscoped().Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes entities deleted before the given time and
+// returns the number of entities removed. Repositories of models with
+// dependent rows override it to remove those too.
+func (r *CRUD[T, ID]) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(new(T))
+	return result.RowsAffected, result.Error
+}
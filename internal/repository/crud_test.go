@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
+	"gorm.io/gorm"
+)
+
+func TestCRUD(t *testing.T) {
+	ctx := context.Background()
+	crud := NewCRUD[model.Product, uint](newTestDB(t))
+
+	product := &model.Product{Name: "Desk", Price: 120, Stock: 3}
+	if err := crud.Create(ctx, product); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if product.ID == 0 {
+		t.Fatal("Create() should set the ID")
+	}
+
+	got, err := crud.GetByID(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "Desk" || got.Stock != 3 {
+		t.Errorf("GetByID() = %+v", got)
+	}
+
+	got.Stock = 0
+	got.Description = "Oak"
+	if err := crud.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	updated, err := crud.GetByID(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Stock != 0 || updated.Description != "Oak" {
+		t.Errorf("Update() should save all fields, got %+v", updated)
+	}
+
+	if err := crud.Delete(ctx, product.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := crud.GetByID(ctx, product.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByID() of a deleted entity should return ErrRecordNotFound, got %v", err)
+	}
+	if deleted, err := crud.CountDeleted(ctx); err != nil || deleted != 1 {
+		t.Errorf("Delete() should soft delete, got %d in the trash (%v)", deleted, err)
+	}
+}
+
+func TestCRUDFindAndCount(t *testing.T) {
+	ctx := context.Background()
+	crud := NewCRUD[model.Product, uint](newTestDB(t))
+
+	for _, name := range []string{"Chair", "Desk", "Lamp", "Shelf"} {
+		if err := crud.Create(ctx, &model.Product{Name: name, Price: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cheap := &model.Product{Name: "Pen", Price: 1}
+	if err := crud.Create(ctx, cheap); err != nil {
+		t.Fatal(err)
+	}
+	if err := crud.Delete(ctx, cheap.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	all := func(db *gorm.DB) *gorm.DB { return db }
+	page := query.Page{Sorts: []query.Sort{{Field: "name", Desc: true}}, Offset: 1, Size: 2}
+	products, err := crud.Find(ctx, all, page)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	// One row more than the page size tells that more rows follow
+	if len(products) != 3 || products[0].Name != "Lamp" || products[1].Name != "Desk" {
+		t.Errorf("Find() returned %v", productNames(products))
+	}
+
+	count, err := crud.Count(ctx, all)
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Count() = %d, deleted entities should not be counted", count)
+	}
+
+	scoped, err := crud.Count(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("name LIKE ?", "%a%") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scoped != 2 {
+		t.Errorf("Count() in the scope = %d, want 2", scoped)
+	}
+
+	first, err := crud.First(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("name = ?", "Shelf") })
+	if err != nil || first.Name != "Shelf" {
+		t.Errorf("First() = %v, %v", first, err)
+	}
+	if _, err := crud.First(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("name = ?", "Pen") }); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("First() of an empty scope should return ErrRecordNotFound, got %v", err)
+	}
+}
+
+func productNames(products []*model.Product) []string {
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return names
+}
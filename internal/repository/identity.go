@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository handles database operations for external identities
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.Identity) error
+	GetByIssuerSubject(ctx context.Context, issuer, subject string) (*model.Identity, error)
+	Update(ctx context.Context, identity *model.Identity) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// Create links a new external identity to a user
+func (r *identityRepository) Create(ctx context.Context, identity *model.Identity) error {
+	return conn(ctx, r.db).Create(identity).Error
+}
+
+// GetByIssuerSubject retrieves the identity of an account at a provider
+func (r *identityRepository) GetByIssuerSubject(ctx context.Context, issuer, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	err := conn(ctx, r.db).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Update updates an identity
+func (r *identityRepository) Update(ctx context.Context, identity *model.Identity) error {
+	return conn(ctx, r.db).Save(identity).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// OAuthClientRepository handles database operations for OAuth clients
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	GetByID(ctx context.Context, id uint) (*model.OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	List(ctx context.Context) ([]*model.OAuthClient, error)
+	Delete(ctx context.Context, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthClientRepository creates a new OAuth client repository
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+// Create creates a new OAuth client
+func (r *oauthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	return conn(ctx, r.db).Create(client).Error
+}
+
+// GetByID retrieves an OAuth client by ID
+func (r *oauthClientRepository) GetByID(ctx context.Context, id uint) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := conn(ctx, r.db).First(&client, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// GetByClientID retrieves an OAuth client by its public client ID
+func (r *oauthClientRepository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := conn(ctx, r.db).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// List retrieves all OAuth clients
+func (r *oauthClientRepository) List(ctx context.Context) ([]*model.OAuthClient, error) {
+	var clients []*model.OAuthClient
+	err := conn(ctx, r.db).Order("id").Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Delete deletes an OAuth client
+func (r *oauthClientRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&model.OAuthClient{}, id).Error
+}
+
+// TouchLastUsed records when the client last obtained a token without touching updated_at
+func (r *oauthClientRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return conn(ctx, r.db).Model(&model.OAuthClient{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -4,66 +4,67 @@ import (
 	"context"
 
 	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	"gorm.io/gorm"
 )
 
 // ProductRepository handles database operations for products
 type ProductRepository interface {
-	Create(ctx context.Context, product *model.Product) error
-	GetByID(ctx context.Context, id uint) (*model.Product, error)
-	List(ctx context.Context, offset, limit int) ([]*model.Product, error)
-	Update(ctx context.Context, product *model.Product) error
-	Delete(ctx context.Context, id uint) error
-	Count(ctx context.Context) (int64, error)
+	Repository[model.Product, uint]
+	TrashRepository[model.Product, uint]
+	List(ctx context.Context, filter *model.ProductFilter, page query.Page) ([]*model.Product, error)
+	Count(ctx context.Context, filter *model.ProductFilter) (int64, error)
 }
 
 type productRepository struct {
-	db *gorm.DB
+	*CRUD[model.Product, uint]
 }
 
 // NewProductRepository creates a new product repository
 func NewProductRepository(db *gorm.DB) ProductRepository {
-	return &productRepository{db: db}
+	return &productRepository{CRUD: NewCRUD[model.Product, uint](db)}
 }
 
-// Create creates a new product
-func (r *productRepository) Create(ctx context.Context, product *model.Product) error {
-	return r.db.WithContext(ctx).Create(product).Error
+// List retrieves the products matching the filter on the page. It fetches one
+// row more than the page size, see query.Page.
+func (r *productRepository) List(ctx context.Context, filter *model.ProductFilter, page query.Page) ([]*model.Product, error) {
+	return r.Find(ctx, productFilterScope(filter), page)
 }
 
-// GetByID retrieves a product by ID
-func (r *productRepository) GetByID(ctx context.Context, id uint) (*model.Product, error) {
-	var product model.Product
-	err := r.db.WithContext(ctx).First(&product, id).Error
-	if err != nil {
-		return nil, err
-	}
-	return &product, nil
-}
-
-// List retrieves a list of products with pagination
-func (r *productRepository) List(ctx context.Context, offset, limit int) ([]*model.Product, error) {
-	var products []*model.Product
-	err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&products).Error
-	if err != nil {
-		return nil, err
-	}
-	return products, nil
+// Count returns the number of products matching the filter
+func (r *productRepository) Count(ctx context.Context, filter *model.ProductFilter) (int64, error) {
+	return r.CRUD.Count(ctx, productFilterScope(filter))
 }
 
-// Update updates a product
-func (r *productRepository) Update(ctx context.Context, product *model.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+func productFilterScope(filter *model.ProductFilter) Scope {
+	return func(db *gorm.DB) *gorm.DB { return filterProducts(db, filter) }
 }
 
-// Delete deletes a product by ID
-func (r *productRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.Product{}, id).Error
-}
-
-// Count returns the total number of products
-func (r *productRepository) Count(ctx context.Context) (int64, error) {
-	var count int64
-	err := r.db.WithContext(ctx).Model(&model.Product{}).Count(&count).Error
-	return count, err
+// filterProducts narrows db down to the products matching the filter
+func filterProducts(db *gorm.DB, filter *model.ProductFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	if filter.Q != "" {
+		db = containsAny(db, filter.Q, "name", "description")
+	}
+	if filter.Name != "" {
+		db = containsAny(db, filter.Name, "name")
+	}
+	if filter.PriceMin != nil {
+		db = db.Where("price >= ?", *filter.PriceMin)
+	}
+	if filter.PriceMax != nil {
+		db = db.Where("price <= ?", *filter.PriceMax)
+	}
+	if filter.StockLT != nil {
+		db = db.Where("stock < ?", *filter.StockLT)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return db
 }
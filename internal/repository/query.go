@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paginate orders db and limits it to the rows of the page. Sort fields must
+// be column names that were checked against a whitelist, see query.ParseSort.
+func paginate(db *gorm.DB, page query.Page) *gorm.DB {
+	sorts := page.OrderBy()
+	if page.Keyset != nil {
+		db = db.Where(after(sorts, page.Keyset.Values))
+	} else if page.Offset > 0 {
+		db = db.Offset(page.Offset)
+	}
+
+	for _, s := range sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Field}, Desc: s.Desc})
+	}
+	return db.Limit(page.Limit())
+}
+
+// after matches the rows that come after the boundary row with the given sort
+// values: (a > x) OR (a = x AND b > y) OR ..., with < for descending fields
+func after(sorts []query.Sort, values []any) clause.Expression {
+	var or []clause.Expression
+	for i, s := range sorts {
+		and := make([]clause.Expression, 0, i+1)
+		for j := range i {
+			and = append(and, clause.Eq{Column: clause.Column{Name: sorts[j].Field}, Value: values[j]})
+		}
+		if s.Desc {
+			and = append(and, clause.Lt{Column: clause.Column{Name: s.Field}, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: clause.Column{Name: s.Field}, Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
+
+// containsAny matches the rows where one of the columns contains s, ignoring
+// case. MySQL and SQLite compare case-insensitively with LIKE, PostgreSQL
+// needs ILIKE.
+func containsAny(db *gorm.DB, s string, columns ...string) *gorm.DB {
+	op := "LIKE"
+	if db.Dialector.Name() == "postgres" {
+		op = "ILIKE"
+	}
+
+	pattern := query.Contains(s)
+	conds := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		conds[i] = column + " " + op + " ? ESCAPE '!'"
+		args[i] = pattern
+	}
+	return db.Where("("+strings.Join(conds, " OR ")+")", args...)
+}
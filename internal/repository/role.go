@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"gorm.io/gorm"
+)
+
+// RoleRepository handles database operations for roles and permissions
+type RoleRepository interface {
+	GetByName(ctx context.Context, name string) (*model.Role, error)
+	List(ctx context.Context) ([]*model.Role, error)
+	FirstOrCreate(ctx context.Context, role *model.Role) error
+	FirstOrCreatePermission(ctx context.Context, permission *model.Permission) error
+	SetPermissions(ctx context.Context, role *model.Role, permissions []*model.Permission) error
+	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	AssignToUser(ctx context.Context, userID uint, role *model.Role) error
+	RevokeFromUser(ctx context.Context, userID uint, role *model.Role) error
+	CountUsers(ctx context.Context, role *model.Role) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// GetByName retrieves a role by name
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := conn(ctx, r.db).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// List retrieves all roles with their permissions
+func (r *roleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := conn(ctx, r.db).Preload("Permissions").Order("name").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// FirstOrCreate loads the role with the given name, creating it if it does not exist
+func (r *roleRepository) FirstOrCreate(ctx context.Context, role *model.Role) error {
+	return conn(ctx, r.db).Where("name = ?", role.Name).FirstOrCreate(role).Error
+}
+
+// FirstOrCreatePermission loads the permission with the given name, creating it if it does not exist
+func (r *roleRepository) FirstOrCreatePermission(ctx context.Context, permission *model.Permission) error {
+	return conn(ctx, r.db).Where("name = ?", permission.Name).FirstOrCreate(permission).Error
+}
+
+// SetPermissions replaces the permissions granted by a role
+func (r *roleRepository) SetPermissions(ctx context.Context, role *model.Role, permissions []*model.Permission) error {
+	return conn(ctx, r.db).Model(role).Association("Permissions").Replace(permissions)
+}
+
+// GetUserRoles retrieves the roles assigned to a user with their permissions
+func (r *roleRepository) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := conn(ctx, r.db).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// AssignToUser assigns a role to a user; assigning a role twice is a no-op
+func (r *roleRepository) AssignToUser(ctx context.Context, userID uint, role *model.Role) error {
+	user := &model.User{ID: userID}
+	return conn(ctx, r.db).Model(user).Association("Roles").Append(role)
+}
+
+// RevokeFromUser removes a role from a user
+func (r *roleRepository) RevokeFromUser(ctx context.Context, userID uint, role *model.Role) error {
+	user := &model.User{ID: userID}
+	return conn(ctx, r.db).Model(user).Association("Roles").Delete(role)
+}
+
+// CountUsers returns the number of users that have the role, not counting
+// users in the trash
+func (r *roleRepository) CountUsers(ctx context.Context, role *model.Role) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Table("user_roles").
+		Joins("JOIN users ON users.id = user_roles.user_id").
+		Where("user_roles.role_id = ? AND users.deleted_at IS NULL", role.ID).
+		Count(&count).Error
+	return count, err
+}
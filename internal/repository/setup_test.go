@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// newTestDB returns an in-memory SQLite database with the schema of the models used in the tests
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.New(&config.DatabaseConfig{Driver: database.DriverSQLite, Path: database.SQLiteMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	if err := db.AutoMigrate(&model.User{}, &model.Product{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// txMaxAttempts is how often a transaction runs before a deadlock or lock
+// wait timeout is returned to the caller
+const txMaxAttempts = 3
+
+// txBackoff is the wait before the first retry, doubled for every further retry
+const txBackoff = 20 * time.Millisecond
+
+type txKey struct{}
+
+// TxManager runs functions in a database transaction. Repository methods
+// called with the context passed to the function join the transaction, so a
+// service can change several repositories atomically:
+//
+//	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+//		if err := userRepo.Update(ctx, user); err != nil {
+//			return err
+//		}
+//		return auditRepo.Create(ctx, entry)
+//	})
+type TxManager interface {
+	// WithinTransaction commits when fn returns nil and rolls back when it
+	// returns an error. Called inside a transaction, it runs fn in a savepoint
+	// that is rolled back on error without ending the outer transaction.
+	// Transactions aborted by a deadlock or lock wait timeout are run again,
+	// so fn must not have side effects outside the database it cannot repeat.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+// WithinTransaction runs fn in a transaction, or in a savepoint of the transaction in ctx
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	run := func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}
+
+	// gorm uses a savepoint when the handle is already in a transaction. Only
+	// the outermost transaction is retried, a deadlock rolls all of it back.
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx).Transaction(run)
+	}
+
+	backoff := txBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.db.WithContext(ctx).Transaction(run)
+		if err == nil || attempt == txMaxAttempts || !database.IsRetryable(err) {
+			return err
+		}
+
+		logger.Warn("Retrying transaction", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// conn returns the transaction in ctx, or db when there is none. Repositories
+// use it for every query so they take part in WithinTransaction.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// reader returns the handle for reads that may be served by a read replica:
+// the transaction in ctx when there is one, the primary when ctx is marked
+// with database.WithPrimary, and a healthy replica otherwise
+func reader(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok || database.UsesPrimary(ctx) {
+		return conn(ctx, db)
+	}
+	return database.Reader(db).WithContext(ctx)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var errTest = errors.New("test error")
+
+// errDeadlock is the error MySQL aborts a transaction with on a deadlock
+var errDeadlock = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+func countProducts(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&model.Product{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestWithinTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	txManager := NewTxManager(db)
+	products := NewProductRepository(db)
+
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return products.Create(ctx, &model.Product{Name: "Committed", Price: 1})
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+
+	err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := products.Create(ctx, &model.Product{Name: "Rolled back", Price: 1}); err != nil {
+			return err
+		}
+		return errTest
+	})
+	if !errors.Is(err, errTest) {
+		t.Fatalf("the error of fn should be returned, got %v", err)
+	}
+
+	if n := countProducts(t, db); n != 1 {
+		t.Errorf("expected only the committed product, got %d products", n)
+	}
+}
+
+func TestWithinTransactionSavepoint(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	txManager := NewTxManager(db)
+	products := NewProductRepository(db)
+
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := products.Create(ctx, &model.Product{Name: "Outer", Price: 1}); err != nil {
+			return err
+		}
+
+		// A failing nested transaction only rolls back its own changes
+		err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := products.Create(ctx, &model.Product{Name: "Inner", Price: 1}); err != nil {
+				return err
+			}
+			return errTest
+		})
+		if !errors.Is(err, errTest) {
+			t.Errorf("the error of the nested fn should be returned, got %v", err)
+		}
+
+		return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			return products.Create(ctx, &model.Product{Name: "Second inner", Price: 1})
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+
+	var names []string
+	if err := db.Model(&model.Product{}).Order("id").Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "Outer" || names[1] != "Second inner" {
+		t.Errorf("expected the outer and the second inner product, got %v", names)
+	}
+}
+
+func TestWithinTransactionRetry(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	txManager := NewTxManager(db)
+	products := NewProductRepository(db)
+
+	attempts := 0
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		attempts++
+		if err := products.Create(ctx, &model.Product{Name: "Retried", Price: 1}); err != nil {
+			return err
+		}
+		if attempts < txMaxAttempts {
+			return errDeadlock
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("a transaction succeeding on the last attempt should commit, got %v", err)
+	}
+	if attempts != txMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", txMaxAttempts, attempts)
+	}
+	if n := countProducts(t, db); n != 1 {
+		t.Errorf("the aborted attempts should be rolled back, got %d products", n)
+	}
+}
+
+func TestWithinTransactionRetryLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"deadlock", errDeadlock, txMaxAttempts},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, txMaxAttempts},
+		{"not retryable", errTest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txManager := NewTxManager(newTestDB(t))
+
+			attempts := 0
+			err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				attempts++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("the last error should be returned, got %v", err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestWithinTransactionRetriesOnlyOutermost(t *testing.T) {
+	txManager := NewTxManager(newTestDB(t))
+
+	outer, inner := 0, 0
+	err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		outer++
+		return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			inner++
+			return errDeadlock
+		})
+	})
+	if !errors.Is(err, errDeadlock) {
+		t.Fatalf("expected the deadlock, got %v", err)
+	}
+	// A deadlock aborts the whole transaction, so the savepoint is not run again on its own
+	if outer != txMaxAttempts || inner != txMaxAttempts {
+		t.Errorf("expected %d runs of the outer and the nested fn, got %d and %d", txMaxAttempts, outer, inner)
+	}
+}
+
+func TestWithinTransactionCancelled(t *testing.T) {
+	txManager := NewTxManager(newTestDB(t))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return errDeadlock
+	})
+	if !errors.Is(err, errDeadlock) || attempts != 1 {
+		t.Errorf("a cancelled context should stop the retries, got %v after %d attempts", err, attempts)
+	}
+}
@@ -2,80 +2,113 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	"gorm.io/gorm"
 )
 
 // UserRepository handles database operations for users
 type UserRepository interface {
-	Create(ctx context.Context, user *model.User) error
-	GetByID(ctx context.Context, id uint) (*model.User, error)
+	Repository[model.User, uint]
+	TrashRepository[model.User, uint]
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
-	List(ctx context.Context, offset, limit int) ([]*model.User, error)
-	Update(ctx context.Context, user *model.User) error
-	Delete(ctx context.Context, id uint) error
-	Count(ctx context.Context) (int64, error)
+	GetByEmailWithDeleted(ctx context.Context, email string) (*model.User, error)
+	List(ctx context.Context, filter *model.UserFilter, page query.Page) ([]*model.User, error)
+	Count(ctx context.Context, filter *model.UserFilter) (int64, error)
 }
 
 type userRepository struct {
-	db *gorm.DB
+	*CRUD[model.User, uint]
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepository{db: db}
+	return &userRepository{CRUD: NewCRUD[model.User, uint](db)}
 }
 
-// Create creates a new user
-func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+// GetByEmail retrieves a user by email
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return r.First(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("email = ?", email) })
 }
 
-// GetByID retrieves a user by ID
-func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
-	var user model.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+// GetByEmailWithDeleted retrieves a user by email, including users in the trash
+func (r *userRepository) GetByEmailWithDeleted(ctx context.Context, email string) (*model.User, error) {
+	return r.First(ctx, func(db *gorm.DB) *gorm.DB { return db.Unscoped().Where("email = ?", email) })
 }
 
-// GetByEmail retrieves a user by email
-func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
-	var user model.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+// List retrieves the users matching the filter on the page. It fetches one
+// row more than the page size, see query.Page.
+func (r *userRepository) List(ctx context.Context, filter *model.UserFilter, page query.Page) ([]*model.User, error) {
+	return r.Find(ctx, userFilterScope(filter), page)
 }
 
-// List retrieves a list of users with pagination
-func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*model.User, error) {
-	var users []*model.User
-	err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&users).Error
-	if err != nil {
-		return nil, err
-	}
-	return users, nil
+// Count returns the number of users matching the filter
+func (r *userRepository) Count(ctx context.Context, filter *model.UserFilter) (int64, error) {
+	return r.CRUD.Count(ctx, userFilterScope(filter))
 }
 
-// Update updates a user
-func (r *userRepository) Update(ctx context.Context, user *model.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+func userFilterScope(filter *model.UserFilter) Scope {
+	return func(db *gorm.DB) *gorm.DB { return filterUsers(db, filter) }
 }
 
-// Delete deletes a user by ID
-func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&model.User{}, id).Error
+// PurgeDeleted permanently removes users deleted before the given time, together
+// with their role assignments, API keys, linked identities and recovery codes.
+// It returns the number of users removed.
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&model.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Table("user_roles").Where("user_id IN ?", ids).Delete(nil).Error; err != nil {
+			return err
+		}
+		for _, dependent := range []interface{}{&model.APIKey{}, &model.Identity{}, &model.RecoveryCode{}} {
+			if err := tx.Where("user_id IN ?", ids).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.User{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
 }
 
-// Count returns the total number of users
-func (r *userRepository) Count(ctx context.Context) (int64, error) {
-	var count int64
-	err := r.db.WithContext(ctx).Model(&model.User{}).Count(&count).Error
-	r.db.WithContext(ctx).Where(&model.User{}).Count(&count)
-	return count, err
+// filterUsers narrows db down to the users matching the filter
+func filterUsers(db *gorm.DB, filter *model.UserFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	if filter.Q != "" {
+		db = containsAny(db, filter.Q, "name", "email")
+	}
+	if filter.Name != "" {
+		db = containsAny(db, filter.Name, "name")
+	}
+	if filter.Email != "" {
+		db = db.Where("email = ?", filter.Email)
+	}
+	if filter.AgeMin != nil {
+		db = db.Where("age >= ?", *filter.AgeMin)
+	}
+	if filter.AgeMax != nil {
+		db = db.Where("age <= ?", *filter.AgeMax)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return db
 }
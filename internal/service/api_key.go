@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyScheme starts every API key, which makes leaked keys easy to recognise
+	apiKeyScheme = "gwt"
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService manages API keys and authenticates requests made with them.
+// It implements middleware.APIKeyValidator.
+type APIKeyService interface {
+	Create(ctx context.Context, userID uint, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error)
+	List(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	GetByID(ctx context.Context, userID, id uint) (*model.APIKey, error)
+	Update(ctx context.Context, userID, id uint, req *model.UpdateAPIKeyRequest) (*model.APIKey, error)
+	Delete(ctx context.Context, userID, id uint) error
+	ValidateAPIKey(ctx context.Context, key string) (*middleware.Claims, uint, error)
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository) APIKeyService {
+	return &apiKeyService{
+		repo:     repo,
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// Create generates a new API key for the user. The returned key is the only
+// time its secret is available.
+func (s *apiKeyService) Create(ctx context.Context, userID uint, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	scopes, err := s.checkScopes(ctx, userID, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpiry(req.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	prefix, key, err := generateAPIKey()
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate api key", err)
+	}
+
+	apiKey := &model.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, apiKey); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to create api key", err)
+	}
+
+	logger.Info("API key created", zap.Uint("user_id", userID), zap.String("prefix", prefix))
+	return &model.CreateAPIKeyResponse{APIKey: *apiKey, Key: key}, nil
+}
+
+// List retrieves the user's API keys
+func (s *apiKeyService) List(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	keys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to list api keys", err)
+	}
+	return keys, nil
+}
+
+// GetByID retrieves one of the user's API keys
+func (s *apiKeyService) GetByID(ctx context.Context, userID, id uint) (*model.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("api key not found", err)
+	}
+	return key, nil
+}
+
+// Update renames an API key or changes its scopes or expiry
+func (s *apiKeyService) Update(ctx context.Context, userID, id uint, req *model.UpdateAPIKeyRequest) (*model.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("api key not found", err)
+	}
+
+	if req.Name != "" {
+		key.Name = req.Name
+	}
+	if req.Scopes != nil {
+		scopes, err := s.checkScopes(ctx, userID, req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		key.Scopes = scopes
+	}
+	if req.ExpiresAt != nil {
+		if err := checkExpiry(req.ExpiresAt); err != nil {
+			return nil, err
+		}
+		key.ExpiresAt = req.ExpiresAt
+	}
+
+	if err := s.repo.Update(ctx, key); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to update api key", err)
+	}
+	return key, nil
+}
+
+// Delete revokes one of the user's API keys
+func (s *apiKeyService) Delete(ctx context.Context, userID, id uint) error {
+	key, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return apperrors.NewNotFoundErrorWithCause("api key not found", err)
+	}
+
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to delete api key", err)
+	}
+
+	logger.Info("API key deleted", zap.Uint("user_id", userID), zap.String("prefix", key.Prefix))
+	return nil
+}
+
+// ValidateAPIKey resolves an API key to claims for its owner. The permissions
+// are the key's scopes that the owner still has, so revoking a role also
+// limits the owner's keys. It returns the key ID alongside the claims.
+func (s *apiKeyService) ValidateAPIKey(ctx context.Context, key string) (*middleware.Claims, uint, error) {
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return nil, 0, apperrors.NewUnauthorizedError("invalid or expired api key")
+	}
+
+	apiKey, err := s.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, apperrors.NewUnauthorizedError("invalid or expired api key")
+	}
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to load api key", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, 0, apperrors.NewUnauthorizedError("invalid or expired api key")
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, 0, apperrors.NewUnauthorizedError("invalid or expired api key")
+	}
+
+	// Read the owner from the primary, keys of a user disabled a moment ago must stop working
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), apiKey.UserID)
+	if err != nil {
+		return nil, 0, apperrors.NewUnauthorizedErrorWithCause("invalid or expired api key", err)
+	}
+	if user.Disabled {
+		return nil, 0, apperrors.NewForbiddenError("account is disabled")
+	}
+
+	ownerPermissions, err := s.userPermissions(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var permissions []string
+	for _, scope := range apiKey.Scopes {
+		if ownerPermissions[scope] {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.repo.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			logger.Warn("Failed to record api key usage", zap.Uint("api_key_id", apiKey.ID), zap.Error(err))
+		}
+	}
+
+	return &middleware.Claims{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Permissions: permissions,
+	}, apiKey.ID, nil
+}
+
+// checkScopes ensures the user holds every requested scope and returns them deduplicated and sorted
+func (s *apiKeyService) checkScopes(ctx context.Context, userID uint, scopes []string) ([]string, error) {
+	ownerPermissions, err := s.userPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, scope := range scopes {
+		if !ownerPermissions[scope] {
+			return nil, apperrors.NewValidationError("scope not granted to user: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// userPermissions returns the set of permissions the user currently has through its roles
+func (s *apiKeyService) userPermissions(ctx context.Context, userID uint) (map[string]bool, error) {
+	roles, err := s.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load user roles", err)
+	}
+
+	_, permissions := roleClaims(roles)
+	set := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set, nil
+}
+
+func checkExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return apperrors.NewValidationError("expires_at must be in the future")
+	}
+	return nil
+}
+
+// generateAPIKey returns a new key of the form gwt_<prefix>_<secret> and its prefix
+func generateAPIKey() (string, string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix := hex.EncodeToString(b)
+
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prefix, apiKeyScheme + "_" + prefix + "_" + secret, nil
+}
+
+// parseAPIKeyPrefix extracts the lookup prefix from a key of the form gwt_<prefix>_<secret>
+func parseAPIKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyScheme || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
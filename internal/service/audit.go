@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// AuditService keeps the audit trail of administrative actions
+type AuditService interface {
+	Record(ctx context.Context, entry *model.AuditLog) error
+	List(ctx context.Context, page, pageSize int) ([]*model.AuditLog, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{
+		repo: repo,
+	}
+}
+
+// Record stores an audit log entry and writes it to the application log
+func (s *auditService) Record(ctx context.Context, entry *model.AuditLog) error {
+	if len(entry.UserAgent) > maxUserAgentLength {
+		entry.UserAgent = entry.UserAgent[:maxUserAgentLength]
+	}
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to write audit log", err)
+	}
+
+	logger.Info("Audit",
+		zap.String("action", entry.Action),
+		zap.Uint("actor_id", entry.ActorID),
+		zap.Uint("target_user_id", entry.TargetUserID),
+		zap.String("reason", entry.Reason),
+		zap.String("ip", entry.IP),
+	)
+	return nil
+}
+
+// List retrieves audit log entries with pagination, newest first
+func (s *auditService) List(ctx context.Context, page, pageSize int) ([]*model.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+
+	entries, err := s.repo.List(ctx, offset, pageSize)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to list audit logs", err)
+	}
+
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to count audit logs", err)
+	}
+
+	return entries, total, nil
+}
@@ -2,46 +2,124 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/IndigoCloud6/go-web-template/internal/config"
 	"github.com/IndigoCloud6/go-web-template/internal/model"
 	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
 	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/notifier"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// minResponseDuration is the minimum time taken by operations that look up an
+// account by email, so that response times do not reveal whether it exists
+const minResponseDuration = 500 * time.Millisecond
+
 // AuthService handles authentication business logic
 type AuthService interface {
-	Authenticate(ctx context.Context, email, password string) (*model.User, error)
+	Authenticate(ctx context.Context, email, password, clientIP string) (*model.User, error)
 	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	UpdateProfile(ctx context.Context, userID uint, req *model.UpdateProfileRequest) (*model.User, error)
+	ChangePassword(ctx context.Context, userID uint, sessionID, clientIP string, req *model.ChangePasswordRequest) error
+	DeleteAccount(ctx context.Context, userID uint, password, clientIP string) error
 }
 
 type authService struct {
-	userRepo repository.UserRepository
+	userRepo       repository.UserRepository
+	userService    UserService
+	limiter        LoginLimiter
+	redis          *redis.Client
+	notifier       notifier.Notifier
+	tokenService   TokenService
+	passwordPolicy *password.Policy
+	passwordHasher *password.Hasher
+	authConfig     *config.AuthConfig
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo repository.UserRepository) AuthService {
+func NewAuthService(userRepo repository.UserRepository, userService UserService, limiter LoginLimiter, redis *redis.Client, notifier notifier.Notifier, tokenService TokenService, passwordPolicy *password.Policy, passwordHasher *password.Hasher, authConfig *config.AuthConfig) AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+		limiter:        limiter,
+		redis:          redis,
+		notifier:       notifier,
+		tokenService:   tokenService,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		authConfig:     authConfig,
 	}
 }
 
-// Authenticate verifies user credentials and returns the user if valid
-func (s *authService) Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+// Authenticate verifies user credentials and returns the user if valid.
+// Failed attempts are counted per email and client IP, and both are locked out
+// for a while once too many attempts failed. Users with MFA keep their failures
+// until the second factor is verified. A password hash made with outdated
+// settings is replaced by a new one while the plain password is at hand.
+func (s *authService) Authenticate(ctx context.Context, email, password, clientIP string) (*model.User, error) {
+	if err := s.limiter.Check(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, apperrors.NewUnauthorizedError("invalid email or password")
+		s.checkDummyPassword(password)
+		return nil, s.loginFailed(ctx, email, clientIP)
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, apperrors.NewUnauthorizedError("invalid email or password")
+	if !s.checkPassword(user, password) {
+		return nil, s.loginFailed(ctx, email, clientIP)
+	}
+
+	// A refused login neither resets the failures nor writes the user
+	if user.Disabled {
+		return nil, apperrors.NewForbiddenError("account is disabled")
+	}
+
+	if s.authConfig.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, apperrors.NewForbiddenError("email address is not verified")
+	}
+
+	// With MFA the login only succeeds with the second factor, which resets
+	// the failures then
+	if !user.MFAEnabled {
+		if err := s.limiter.RecordSuccess(ctx, email); err != nil {
+			logger.Warn("Failed to reset login failures", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+	}
+
+	if s.passwordHasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, password)
 	}
 
 	return user, nil
 }
 
+// loginFailed records a failed login and returns the error reported to the client
+func (s *authService) loginFailed(ctx context.Context, email, clientIP string) error {
+	if err := s.limiter.RecordFailure(ctx, email, clientIP); err != nil {
+		return err
+	}
+	return apperrors.NewUnauthorizedError("invalid email or password")
+}
+
 // GetUserByID retrieves a user by ID
 func (s *authService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
 	user, err := s.userRepo.GetByID(ctx, id)
@@ -50,3 +128,270 @@ func (s *authService) GetUserByID(ctx context.Context, id uint) (*model.User, er
 	}
 	return user, nil
 }
+
+// ForgotPassword sends a password reset link to the user with the given email.
+// It succeeds whether or not the account exists and always takes at least
+// minResponseDuration, so callers cannot use it to enumerate accounts.
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	defer waitUntil(ctx, time.Now().Add(minResponseDuration))
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load user", err)
+	}
+	if user.Disabled {
+		return nil
+	}
+
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to generate reset token", err)
+	}
+	tokenHash := hashToken(token)
+
+	// Only the latest link stays valid; drop the previous token of this user, if any
+	previous, err := s.redis.GetSet(ctx, passwordResetUserKey(user.ID), tokenHash).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return apperrors.NewInternalErrorWithCause("failed to store reset token", err)
+	}
+
+	ttl := s.passwordResetTTL()
+	pipe := s.redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, passwordResetKey(previous))
+	}
+	pipe.Set(ctx, passwordResetKey(tokenHash), user.ID, ttl)
+	pipe.Expire(ctx, passwordResetUserKey(user.ID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to store reset token", err)
+	}
+
+	msg := &notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use the link below to choose a new password. It expires in %d minutes.\n\n%s",
+			int(ttl.Minutes()), tokenLink(s.authConfig.PasswordResetURL, token)),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		// Do not report delivery problems to the caller, it would reveal that the account exists
+		logger.Error("Failed to send password reset link", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a token sent by ForgotPassword.
+// The token can be used once, and all existing sessions of the user are revoked.
+// A password rejected by the policy does not use up the token.
+func (s *authService) ResetPassword(ctx context.Context, token, password string) error {
+	tokenHash := hashToken(token)
+
+	userIDStr, err := s.redis.Get(ctx, passwordResetKey(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return apperrors.NewUnauthorizedError("invalid or expired reset token")
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load reset token", err)
+	}
+
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to decode reset token", err)
+	}
+
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), uint(userID))
+	if err != nil {
+		return apperrors.NewUnauthorizedErrorWithCause("invalid or expired reset token", err)
+	}
+	if err := s.passwordPolicy.Validate("password", password, user.Email); err != nil {
+		return err
+	}
+
+	// GETDEL makes the token single use even with concurrent requests
+	if err := s.redis.GetDel(ctx, passwordResetKey(tokenHash)).Err(); errors.Is(err, redis.Nil) {
+		return apperrors.NewUnauthorizedError("invalid or expired reset token")
+	} else if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load reset token", err)
+	}
+	s.redis.Del(ctx, passwordResetUserKey(uint(userID)))
+
+	// Update hashes the password, revokes the user's tokens and clears the cache
+	if _, err := s.userService.Update(ctx, uint(userID), &model.UpdateUserRequest{Password: password}); err != nil {
+		return err
+	}
+
+	logger.Info("Password reset", zap.Uint64("user_id", userID))
+	return nil
+}
+
+// UpdateProfile updates the name, email and age of the user. A new email has
+// to be verified before it replaces the current one.
+func (s *authService) UpdateProfile(ctx context.Context, userID uint, req *model.UpdateProfileRequest) (*model.User, error) {
+	return s.userService.Update(ctx, userID, &model.UpdateUserRequest{
+		Name:  req.Name,
+		Email: req.Email,
+		Age:   req.Age,
+	})
+}
+
+// ChangePassword replaces the user's password after checking the current one.
+// Every session except the one identified by sessionID is logged out.
+func (s *authService) ChangePassword(ctx context.Context, userID uint, sessionID, clientIP string, req *model.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	if err := s.reauthenticate(ctx, user, req.CurrentPassword, clientIP); err != nil {
+		return err
+	}
+	if err := s.passwordPolicy.Validate("new_password", req.NewPassword, user.Email); err != nil {
+		return err
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return apperrors.NewFieldValidationError("password does not meet the requirements",
+			apperrors.FieldError{Field: "new_password", Message: "must differ from the current password"})
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to hash password", err)
+	}
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to update user", err)
+	}
+	s.clearUserCache(ctx, userID)
+
+	// A reset link sent before the change must not be able to undo it
+	if previous, err := s.redis.GetDel(ctx, passwordResetUserKey(userID)).Result(); err == nil {
+		s.redis.Del(ctx, passwordResetKey(previous))
+	}
+
+	if err := s.tokenService.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	logger.Info("Password changed", zap.Uint("user_id", userID))
+	return nil
+}
+
+// DeleteAccount deletes the user after checking the password again
+func (s *authService) DeleteAccount(ctx context.Context, userID uint, password, clientIP string) error {
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	if err := s.reauthenticate(ctx, user, password, clientIP); err != nil {
+		return err
+	}
+
+	// Delete revokes the user's tokens and clears the cache
+	if err := s.userService.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Info("Account deleted by its owner", zap.Uint("user_id", userID))
+	return nil
+}
+
+// reauthenticate checks the password of an already authenticated user before a
+// sensitive change. Failures count towards the same lockout as failed logins.
+func (s *authService) reauthenticate(ctx context.Context, user *model.User, password, clientIP string) error {
+	if err := s.limiter.Check(ctx, user.Email, clientIP); err != nil {
+		return err
+	}
+
+	if !s.checkPassword(user, password) {
+		if err := s.limiter.RecordFailure(ctx, user.Email, clientIP); err != nil {
+			return err
+		}
+		return apperrors.NewUnauthorizedError("password is incorrect")
+	}
+
+	if err := s.limiter.RecordSuccess(ctx, user.Email); err != nil {
+		logger.Warn("Failed to reset login failures", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// clearUserCache drops the cached user and user lists
+func (s *authService) clearUserCache(ctx context.Context, userID uint) {
+	newUserCache(s.redis).Invalidate(ctx, userID)
+}
+
+// checkPassword reports whether the password matches the user's stored hash
+func (s *authService) checkPassword(user *model.User, password string) bool {
+	ok, err := s.passwordHasher.Verify(password, user.Password)
+	if err != nil {
+		logger.Error("Failed to verify password hash", zap.Uint("user_id", user.ID), zap.Error(err))
+		return false
+	}
+	return ok
+}
+
+// checkDummyPassword verifies the password against a hash of a random
+// password, so a login with an unknown email takes as long as one with a wrong
+// password and the response time does not reveal which emails are registered
+func (s *authService) checkDummyPassword(password string) {
+	s.dummyHashOnce.Do(func() {
+		secret, err := generateRandomToken(16)
+		if err == nil {
+			s.dummyHash, err = s.passwordHasher.Hash(secret)
+		}
+		if err != nil {
+			logger.Error("Failed to create dummy password hash", zap.Error(err))
+		}
+	})
+	if s.dummyHash != "" {
+		s.passwordHasher.Verify(password, s.dummyHash)
+	}
+}
+
+// rehashPassword replaces the user's password hash with one made with the
+// current settings. Failures are only logged, the login itself succeeded.
+func (s *authService) rehashPassword(ctx context.Context, user *model.User, password string) {
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		logger.Warn("Failed to rehash password", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		logger.Warn("Failed to store rehashed password", zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	s.clearUserCache(ctx, user.ID)
+	logger.Info("Password hash upgraded", zap.Uint("user_id", user.ID))
+}
+
+func (s *authService) passwordResetTTL() time.Duration {
+	minutes := s.authConfig.PasswordResetTTLMinutes
+	if minutes <= 0 {
+		minutes = 30 // default to 30 minutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// waitUntil blocks until the deadline has passed or the context is done
+func waitUntil(ctx context.Context, deadline time.Time) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func passwordResetKey(tokenHash string) string {
+	return fmt.Sprintf("password_reset:%s", tokenHash)
+}
+
+func passwordResetUserKey(userID uint) string {
+	return fmt.Sprintf("password_reset:user:%d", userID)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+func TestAuthenticateUnknownEmail(t *testing.T) {
+	ctx := context.Background()
+	env := newMFATestEnv(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := env.auth.Authenticate(ctx, "nobody@example.com", "secret", testClientIP); !apperrors.IsUnauthorizedError(err) {
+			t.Fatalf("an unknown email should be rejected like a wrong password, got %v", err)
+		}
+	}
+
+	// The password is still verified, against a hash of a random password
+	if env.auth.(*authService).dummyHash == "" {
+		t.Error("an unknown email should be checked against the dummy hash")
+	}
+	if err := env.limiter.Check(ctx, "nobody@example.com", testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Errorf("unknown emails should count towards the lockout, got %v", err)
+	}
+}
+
+func TestAuthenticateDisabledKeepsFailures(t *testing.T) {
+	ctx := context.Background()
+	env := newMFATestEnv(t)
+	user, _ := env.createMFAUser(t, "disabled@example.com")
+	if err := env.db.Model(user).Updates(map[string]interface{}{"mfa_enabled": false, "disabled": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := env.auth.Authenticate(ctx, user.Email, "wrong", testClientIP); !apperrors.IsUnauthorizedError(err) {
+			t.Fatal(err)
+		}
+	}
+	if _, err := env.auth.Authenticate(ctx, user.Email, "secret", testClientIP); !apperrors.IsForbiddenError(err) {
+		t.Fatalf("a disabled account should be refused, got %v", err)
+	}
+
+	// The refused login with the right password does not reset the failures
+	if _, err := env.auth.Authenticate(ctx, user.Email, "wrong", testClientIP); !apperrors.IsUnauthorizedError(err) {
+		t.Fatal(err)
+	}
+	if err := env.limiter.Check(ctx, user.Email, testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Errorf("the failures before the refused login should still count, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+)
+
+func TestEntityCacheGet(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestRedis(t)
+	cache := newEntityCache[model.Product, uint](client, "product", "products")
+
+	loads := 0
+	load := func(ctx context.Context, id uint) (*model.Product, error) {
+		loads++
+		if !database.UsesPrimary(ctx) {
+			t.Error("a cache miss should load from the primary")
+		}
+		return &model.Product{ID: id, Name: "Lamp"}, nil
+	}
+
+	// A miss loads the entity and caches it
+	product, err := cache.Get(ctx, 7, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Name != "Lamp" || loads != 1 {
+		t.Fatalf("expected one load of the entity, got %+v after %d loads", product, loads)
+	}
+	if !server.Exists("product:7") {
+		t.Fatal("the entity should be cached under product:7")
+	}
+
+	// A hit is served from Redis
+	product, err = cache.Get(ctx, 7, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Name != "Lamp" || loads != 1 {
+		t.Errorf("a cached entity should not be loaded again, got %d loads", loads)
+	}
+}
+
+func TestEntityCacheGetError(t *testing.T) {
+	client, server := newTestRedis(t)
+	cache := newEntityCache[model.Product, uint](client, "product", "products")
+
+	errMissing := errors.New("missing")
+	_, err := cache.Get(context.Background(), 7, func(ctx context.Context, id uint) (*model.Product, error) {
+		return nil, errMissing
+	})
+	if !errors.Is(err, errMissing) {
+		t.Errorf("the error of load should be returned unchanged, got %v", err)
+	}
+	if server.Exists("product:7") {
+		t.Error("a failed load should not be cached")
+	}
+}
+
+func TestEntityCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestRedis(t)
+	cache := newEntityCache[model.Product, uint](client, "product", "products")
+
+	for _, key := range []string{"product:7", "product:8", "products:list:1:10", "products:list:2:10", "users:list:1:10"} {
+		server.Set(key, "{}")
+	}
+
+	cache.Invalidate(ctx, 7)
+
+	for key, cached := range map[string]bool{
+		"product:7":          false,
+		"products:list:1:10": false,
+		"products:list:2:10": false,
+		"product:8":          true, // Other entities stay cached
+		"users:list:1:10":    true, // Lists of other resources stay cached
+	} {
+		if server.Exists(key) != cached {
+			t.Errorf("after Invalidate(7) %s cached = %v, want %v", key, !cached, cached)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/notifier"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationService sends verification links and confirms email addresses,
+// both for new accounts and for email changes
+type EmailVerificationService interface {
+	SendVerification(ctx context.Context, user *model.User, email string) error
+	Verify(ctx context.Context, token string) (*model.User, error)
+	Resend(ctx context.Context, email string) error
+}
+
+// emailVerificationRecord is stored in Redis under the hash of the verification token.
+// Email is the address being verified, either the user's email or its pending email.
+type emailVerificationRecord struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type emailVerificationService struct {
+	userRepo    repository.UserRepository
+	roleService RoleService
+	redis       *redis.Client
+	notifier    notifier.Notifier
+	authConfig  *config.AuthConfig
+}
+
+// NewEmailVerificationService creates a new email verification service
+func NewEmailVerificationService(userRepo repository.UserRepository, roleService RoleService, redis *redis.Client, notifier notifier.Notifier, authConfig *config.AuthConfig) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:    userRepo,
+		roleService: roleService,
+		redis:       redis,
+		notifier:    notifier,
+		authConfig:  authConfig,
+	}
+}
+
+// SendVerification sends a verification link for the given address of the user.
+// Only the most recent link of a user stays valid.
+func (s *emailVerificationService) SendVerification(ctx context.Context, user *model.User, email string) error {
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to generate verification token", err)
+	}
+	tokenHash := hashToken(token)
+
+	recordJSON, err := json.Marshal(&emailVerificationRecord{UserID: user.ID, Email: email})
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to encode verification token", err)
+	}
+
+	previous, err := s.redis.GetSet(ctx, emailVerificationUserKey(user.ID), tokenHash).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return apperrors.NewInternalErrorWithCause("failed to store verification token", err)
+	}
+
+	ttl := s.ttl()
+	pipe := s.redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, emailVerificationKey(previous))
+	}
+	pipe.Set(ctx, emailVerificationKey(tokenHash), recordJSON, ttl)
+	pipe.Expire(ctx, emailVerificationUserKey(user.ID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to store verification token", err)
+	}
+
+	msg := &notifier.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Use the link below to verify your email address. It expires in %d hours.\n\n%s",
+			int(ttl.Hours()), tokenLink(s.authConfig.EmailVerificationURL, token)),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to send verification email", err)
+	}
+	return nil
+}
+
+// Verify confirms the address a token was issued for. For a pending email change
+// the pending address replaces the current one.
+func (s *emailVerificationService) Verify(ctx context.Context, token string) (*model.User, error) {
+	tokenHash := hashToken(token)
+
+	cached, err := s.redis.GetDel(ctx, emailVerificationKey(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, apperrors.NewUnauthorizedError("invalid or expired verification token")
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load verification token", err)
+	}
+
+	var record emailVerificationRecord
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to decode verification token", err)
+	}
+	s.redis.Del(ctx, emailVerificationUserKey(record.UserID))
+
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), record.UserID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	now := time.Now()
+	switch record.Email {
+	case user.Email:
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+	case user.PendingEmail:
+		// Another account may have taken the address since the change was requested
+		existingUser, err := s.userRepo.GetByEmailWithDeleted(ctx, record.Email)
+		if err == nil && existingUser.ID != user.ID {
+			return nil, apperrors.NewConflictError("email already exists")
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewInternalErrorWithCause("failed to check email", err)
+		}
+
+		user.Email = record.Email
+		user.PendingEmail = ""
+		user.EmailVerifiedAt = &now
+	default:
+		// The email was changed again after this token was issued
+		return nil, apperrors.NewUnauthorizedError("invalid or expired verification token")
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to update user", err)
+	}
+
+	// Clear cache
+	newUserCache(s.redis).Invalidate(ctx, user.ID)
+
+	logger.Info("Email verified", zap.Uint("user_id", user.ID))
+
+	// The bootstrap admin is promoted once it has proven to own the address
+	if err := s.roleService.BootstrapAdmin(ctx); err != nil {
+		logger.Error("Failed to bootstrap admin", zap.Error(err))
+	}
+	return user, nil
+}
+
+// Resend sends a new verification link for an unverified account or a pending email change.
+// Like ForgotPassword it does not reveal whether an account exists for the email.
+func (s *emailVerificationService) Resend(ctx context.Context, email string) error {
+	defer waitUntil(ctx, time.Now().Add(minResponseDuration))
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load user", err)
+	}
+
+	target := user.PendingEmail
+	if target == "" {
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+		target = user.Email
+	}
+
+	if err := s.SendVerification(ctx, user, target); err != nil {
+		logger.Error("Failed to resend verification email", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *emailVerificationService) ttl() time.Duration {
+	hours := s.authConfig.EmailVerificationTTLHours
+	if hours <= 0 {
+		hours = 24 // default to 24 hours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func emailVerificationKey(tokenHash string) string {
+	return fmt.Sprintf("email_verification:%s", tokenHash)
+}
+
+func emailVerificationUserKey(userID uint) string {
+	return fmt.Sprintf("email_verification:user:%d", userID)
+}
+
+// tokenLink appends the token to a link as ?token=, or returns the bare token when no link is configured
+func tokenLink(link, token string) string {
+	if link == "" {
+		return token
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return token
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// LoginLimiter counts failed logins per email and per client IP and locks
+// either out for an exponentially growing period once a threshold is exceeded
+type LoginLimiter interface {
+	Check(ctx context.Context, email, clientIP string) error
+	RecordFailure(ctx context.Context, email, clientIP string) error
+	RecordSuccess(ctx context.Context, email string) error
+	List(ctx context.Context) ([]*model.Lockout, error)
+	Clear(ctx context.Context, kind, key string) error
+}
+
+type loginLimiter struct {
+	redis  *redis.Client
+	config *config.LockoutConfig
+}
+
+// NewLoginLimiter creates a new login limiter
+func NewLoginLimiter(redis *redis.Client, lockoutConfig *config.LockoutConfig) LoginLimiter {
+	return &loginLimiter{
+		redis:  redis,
+		config: lockoutConfig,
+	}
+}
+
+// Check returns a TooManyRequests error while the email or the client IP is locked out
+func (l *loginLimiter) Check(ctx context.Context, email, clientIP string) error {
+	pipe := l.redis.Pipeline()
+	emailTTL := pipe.PTTL(ctx, lockoutKey(model.LockoutTypeEmail, normalizeEmail(email)))
+	ipTTL := pipe.PTTL(ctx, lockoutKey(model.LockoutTypeIP, clientIP))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to check login lockout", err)
+	}
+
+	retryAfter := emailTTL.Val()
+	if ipTTL.Val() > retryAfter {
+		retryAfter = ipTTL.Val()
+	}
+	// PTTL is negative for keys that do not exist
+	if retryAfter > 0 {
+		return apperrors.NewTooManyRequestsError("too many failed login attempts, try again later", retryAfter)
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login and starts a lockout when a threshold is exceeded
+func (l *loginLimiter) RecordFailure(ctx context.Context, email, clientIP string) error {
+	counters := []struct {
+		kind      string
+		key       string
+		threshold int
+	}{
+		{model.LockoutTypeEmail, normalizeEmail(email), l.maxAttemptsPerEmail()},
+		{model.LockoutTypeIP, clientIP, l.maxAttemptsPerIP()},
+	}
+
+	for _, c := range counters {
+		if c.key == "" {
+			continue
+		}
+
+		// The window starts with the first failure. Setting it in the same
+		// transaction on every failure means a counter cannot be left
+		// without expiry when a request fails between the two commands.
+		pipe := l.redis.TxPipeline()
+		incr := pipe.Incr(ctx, failuresKey(c.kind, c.key))
+		pipe.ExpireNX(ctx, failuresKey(c.kind, c.key), l.window())
+		if _, err := pipe.Exec(ctx); err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to record login failure", err)
+		}
+		failures := incr.Val()
+
+		if failures < int64(c.threshold) {
+			continue
+		}
+
+		duration := l.lockoutDuration(failures - int64(c.threshold))
+		if err := l.redis.Set(ctx, lockoutKey(c.kind, c.key), failures, duration).Err(); err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to record login failure", err)
+		}
+
+		logger.Warn("Login locked out",
+			zap.String("type", c.kind),
+			zap.String("key", c.key),
+			zap.Int64("failures", failures),
+			zap.Duration("duration", duration),
+		)
+	}
+
+	return nil
+}
+
+// RecordSuccess resets the failure counter of the email. The client IP keeps
+// its counter, so one valid account cannot be used to reset guessing on others.
+func (l *loginLimiter) RecordSuccess(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if err := l.redis.Del(ctx, failuresKey(model.LockoutTypeEmail, email), lockoutKey(model.LockoutTypeEmail, email)).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to reset login failures", err)
+	}
+	return nil
+}
+
+// List returns all active lockouts
+func (l *loginLimiter) List(ctx context.Context) ([]*model.Lockout, error) {
+	lockouts := []*model.Lockout{}
+
+	iter := l.redis.Scan(ctx, 0, "login_lockout:*", 100).Iterator()
+	for iter.Next(ctx) {
+		redisKey := iter.Val()
+		kind, key, ok := strings.Cut(strings.TrimPrefix(redisKey, "login_lockout:"), ":")
+		if !ok {
+			continue
+		}
+
+		pipe := l.redis.Pipeline()
+		failures := pipe.Get(ctx, redisKey)
+		ttl := pipe.PTTL(ctx, redisKey)
+		if _, err := pipe.Exec(ctx); errors.Is(err, redis.Nil) {
+			continue // expired in the meantime
+		} else if err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to load lockout", err)
+		}
+
+		count, _ := failures.Int64()
+		lockouts = append(lockouts, &model.Lockout{
+			Type:       kind,
+			Key:        key,
+			Failures:   count,
+			RetryAfter: int64(ttl.Val().Round(time.Second).Seconds()),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to list lockouts", err)
+	}
+
+	return lockouts, nil
+}
+
+// Clear lifts a lockout and resets its failure counter
+func (l *loginLimiter) Clear(ctx context.Context, kind, key string) error {
+	switch kind {
+	case model.LockoutTypeEmail:
+		key = normalizeEmail(key)
+	case model.LockoutTypeIP:
+	default:
+		return apperrors.NewValidationError("lockout type must be email or ip")
+	}
+
+	deleted, err := l.redis.Del(ctx, lockoutKey(kind, key), failuresKey(kind, key)).Result()
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to clear lockout", err)
+	}
+	if deleted == 0 {
+		return apperrors.NewNotFoundError("lockout not found")
+	}
+
+	logger.Info("Login lockout cleared", zap.String("type", kind), zap.String("key", key))
+	return nil
+}
+
+// lockoutDuration doubles the base duration for every failure past the threshold
+func (l *loginLimiter) lockoutDuration(excess int64) time.Duration {
+	base := time.Duration(l.config.BaseLockoutSeconds) * time.Second
+	if base <= 0 {
+		base = 30 * time.Second // default to 30 seconds
+	}
+	limit := time.Duration(l.config.MaxLockoutMinutes) * time.Minute
+	if limit <= 0 {
+		limit = time.Hour // default to 1 hour
+	}
+
+	duration := base
+	for i := int64(0); i < excess && duration < limit; i++ {
+		duration *= 2
+	}
+	if duration > limit {
+		duration = limit
+	}
+	return duration
+}
+
+func (l *loginLimiter) window() time.Duration {
+	minutes := l.config.WindowMinutes
+	if minutes <= 0 {
+		minutes = 15 // default to 15 minutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func (l *loginLimiter) maxAttemptsPerEmail() int {
+	if l.config.MaxAttemptsPerEmail <= 0 {
+		return 5
+	}
+	return l.config.MaxAttemptsPerEmail
+}
+
+func (l *loginLimiter) maxAttemptsPerIP() int {
+	if l.config.MaxAttemptsPerIP <= 0 {
+		return 20
+	}
+	return l.config.MaxAttemptsPerIP
+}
+
+func failuresKey(kind, key string) string {
+	return fmt.Sprintf("login_failures:%s:%s", kind, key)
+}
+
+func lockoutKey(kind, key string) string {
+	return fmt.Sprintf("login_lockout:%s:%s", kind, key)
+}
+
+// normalizeEmail makes lockouts case insensitive, like email addresses
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+func TestRecordFailureWindow(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestRedis(t)
+	limiter := NewLoginLimiter(client, &config.LockoutConfig{MaxAttemptsPerEmail: 3, MaxAttemptsPerIP: 100, WindowMinutes: 15})
+	key := failuresKey(model.LockoutTypeEmail, "window@example.com")
+
+	if err := limiter.RecordFailure(ctx, "Window@example.com", testClientIP); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(key); ttl != 15*time.Minute {
+		t.Fatalf("the first failure should start the window, TTL = %v", ttl)
+	}
+
+	// Later failures neither extend the window nor leave it unset
+	server.FastForward(10 * time.Minute)
+	if err := limiter.RecordFailure(ctx, "window@example.com", testClientIP); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(key); ttl != 5*time.Minute {
+		t.Errorf("a later failure should keep the window, TTL = %v", ttl)
+	}
+	if err := client.Persist(ctx, key).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.RecordFailure(ctx, "window@example.com", testClientIP); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(key); ttl != 15*time.Minute {
+		t.Errorf("a counter without expiry should get one, TTL = %v", ttl)
+	}
+
+	if err := limiter.Check(ctx, "window@example.com", testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Errorf("the third failure should lock the email out, got %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/pquerna/otp/totp"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMFAIssuer    = "go-web-template"
+	mfaEnrollmentTTL    = 10 * time.Minute
+	mfaChallengeTTL     = 5 * time.Minute
+	mfaMaxAttempts      = 5
+	totpReplayWindowTTL = 90 * time.Second // covers the accepted clock skew of one period each way
+
+	recoveryCodeCount      = 10
+	recoveryCodeHalfLength = 5
+	recoveryCodeAlphabet   = "abcdefghjkmnpqrstuvwxyz23456789" // no look-alike characters
+)
+
+// MFAService manages TOTP enrollment, recovery codes and the second login step
+type MFAService interface {
+	BeginEnrollment(ctx context.Context, userID uint) (*model.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	CreateChallenge(ctx context.Context, user *model.User) (*model.MFAChallenge, error)
+	VerifyChallenge(ctx context.Context, mfaToken, code, clientIP string) (*model.User, error)
+}
+
+type mfaService struct {
+	repo       repository.MFARepository
+	userRepo   repository.UserRepository
+	limiter    LoginLimiter
+	redis      *redis.Client
+	authConfig *config.AuthConfig
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(repo repository.MFARepository, userRepo repository.UserRepository, limiter LoginLimiter, redis *redis.Client, authConfig *config.AuthConfig) MFAService {
+	return &mfaService{
+		repo:       repo,
+		userRepo:   userRepo,
+		limiter:    limiter,
+		redis:      redis,
+		authConfig: authConfig,
+	}
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. It only takes
+// effect once confirmed with a code generated from it.
+func (s *mfaService) BeginEnrollment(ctx context.Context, userID uint) (*model.MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+	if user.MFAEnabled {
+		return nil, apperrors.NewConflictError("two-factor authentication is already enabled")
+	}
+
+	issuer := s.authConfig.MFAIssuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate totp secret", err)
+	}
+
+	if err := s.redis.Set(ctx, mfaEnrollmentKey(userID), key.Secret(), mfaEnrollmentTTL).Err(); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store totp secret", err)
+	}
+
+	return &model.MFAEnrollment{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves the authenticator works,
+// and returns the recovery codes
+func (s *mfaService) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	secret, err := s.redis.Get(ctx, mfaEnrollmentKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, apperrors.NewValidationError("no pending two-factor enrollment")
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load totp secret", err)
+	}
+
+	if !totp.Validate(code, secret) {
+		return nil, apperrors.NewValidationError("invalid code")
+	}
+
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+	if user.MFAEnabled {
+		return nil, apperrors.NewConflictError("two-factor authentication is already enabled")
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFAEnabled = true
+	user.MFASecret = secret
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to enable two-factor authentication", err)
+	}
+
+	s.redis.Del(ctx, mfaEnrollmentKey(userID))
+	newUserCache(s.redis).Invalidate(ctx, userID)
+
+	logger.Info("Two-factor authentication enabled", zap.Uint("user_id", userID))
+	return codes, nil
+}
+
+// Disable turns MFA off after checking a TOTP or recovery code
+func (s *mfaService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.enabledUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to disable two-factor authentication", err)
+	}
+
+	if err := s.repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to delete recovery codes", err)
+	}
+
+	newUserCache(s.redis).Invalidate(ctx, userID)
+
+	logger.Info("Two-factor authentication disabled", zap.Uint("user_id", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a TOTP or recovery code
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.enabledUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// CreateChallenge starts the second login step for a user whose password was verified
+func (s *mfaService) CreateChallenge(ctx context.Context, user *model.User) (*model.MFAChallenge, error) {
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate mfa token", err)
+	}
+
+	if err := s.redis.Set(ctx, mfaChallengeKey(hashToken(token)), user.ID, mfaChallengeTTL).Err(); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store mfa challenge", err)
+	}
+
+	return &model.MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int64(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyChallenge completes the login started by CreateChallenge. A challenge
+// can be completed once and is dropped after too many wrong codes. Wrong codes
+// also count as failed logins of the user, so starting new challenges does not
+// allow further guesses once the account is locked out.
+func (s *mfaService) VerifyChallenge(ctx context.Context, mfaToken, code, clientIP string) (*model.User, error) {
+	key := mfaChallengeKey(hashToken(mfaToken))
+
+	userIDStr, err := s.redis.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, apperrors.NewUnauthorizedError("invalid or expired mfa token")
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load mfa challenge", err)
+	}
+
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to decode mfa challenge", err)
+	}
+
+	user, err := s.enabledUser(ctx, uint(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.limiter.Check(ctx, user.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		attempts, incrErr := s.redis.Incr(ctx, mfaAttemptsKey(key)).Result()
+		if incrErr == nil && attempts == 1 {
+			s.redis.Expire(ctx, mfaAttemptsKey(key), mfaChallengeTTL)
+		}
+		if attempts >= mfaMaxAttempts {
+			s.redis.Del(ctx, key, mfaAttemptsKey(key))
+		}
+		if apperrors.IsUnauthorizedError(err) {
+			if err := s.limiter.RecordFailure(ctx, user.Email, clientIP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// Only one request can complete the challenge
+	deleted, err := s.redis.Del(ctx, key, mfaAttemptsKey(key)).Result()
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to complete mfa challenge", err)
+	}
+	if deleted == 0 {
+		return nil, apperrors.NewUnauthorizedError("invalid or expired mfa token")
+	}
+
+	if err := s.limiter.RecordSuccess(ctx, user.Email); err != nil {
+		logger.Warn("Failed to reset login failures", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	if user.Disabled {
+		return nil, apperrors.NewForbiddenError("account is disabled")
+	}
+	return user, nil
+}
+
+// enabledUser loads the user and checks that MFA is enabled
+func (s *mfaService) enabledUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+	if !user.MFAEnabled {
+		return nil, apperrors.NewValidationError("two-factor authentication is not enabled")
+	}
+	return user, nil
+}
+
+// checkCode accepts a TOTP code or an unused recovery code. A TOTP code is
+// rejected if it was already used, so an intercepted code cannot be replayed.
+func (s *mfaService) checkCode(ctx context.Context, user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if totp.Validate(code, user.MFASecret) {
+		firstUse, err := s.redis.SetNX(ctx, totpUsedKey(user.ID, code), 1, totpReplayWindowTTL).Result()
+		if err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to check totp code", err)
+		}
+		if !firstUse {
+			return apperrors.NewUnauthorizedError("code has already been used")
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to check recovery code", err)
+	}
+	if !used {
+		return apperrors.NewUnauthorizedError("invalid code")
+	}
+
+	logger.Info("Recovery code used", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes, stores their hashes
+// and returns the codes in plain text
+func (s *mfaService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to generate recovery codes", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store recovery codes", err)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	// Bytes above the largest multiple of the alphabet size are skipped to avoid modulo bias
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+
+	var sb strings.Builder
+	buf := make([]byte, 1)
+	for n := 0; n < recoveryCodeHalfLength*2; {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if int(buf[0]) >= limit {
+			continue
+		}
+		if n == recoveryCodeHalfLength {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(buf[0])%len(recoveryCodeAlphabet)])
+		n++
+	}
+	return sb.String(), nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and dash insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
+
+func mfaEnrollmentKey(userID uint) string {
+	return fmt.Sprintf("mfa_enrollment:%d", userID)
+}
+
+func mfaChallengeKey(tokenHash string) string {
+	return fmt.Sprintf("mfa_challenge:%s", tokenHash)
+}
+
+func mfaAttemptsKey(challengeKey string) string {
+	return challengeKey + ":attempts"
+}
+
+func totpUsedKey(userID uint, code string) string {
+	return fmt.Sprintf("mfa_totp_used:%d:%s", userID, code)
+}
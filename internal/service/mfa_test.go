@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/pquerna/otp/totp"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const testClientIP = "192.0.2.1"
+
+type mfaTestEnv struct {
+	db      *gorm.DB
+	auth    AuthService
+	mfa     MFAService
+	limiter LoginLimiter
+	redis   *redis.Client
+}
+
+func newMFATestEnv(t *testing.T) *mfaTestEnv {
+	t.Helper()
+	db := newTestDB(t)
+	client, _ := newTestRedis(t)
+	userRepo := repository.NewUserRepository(db)
+	hasher, err := password.NewHasher(&config.PasswordConfig{Algorithm: password.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewLoginLimiter(client, &config.LockoutConfig{MaxAttemptsPerEmail: 3, MaxAttemptsPerIP: 100})
+	return &mfaTestEnv{
+		db:      db,
+		auth:    NewAuthService(userRepo, nil, limiter, client, nil, nil, nil, hasher, &config.AuthConfig{}),
+		mfa:     NewMFAService(repository.NewMFARepository(db), userRepo, limiter, client, &config.AuthConfig{}),
+		limiter: limiter,
+		redis:   client,
+	}
+}
+
+// createMFAUser stores a user with the password "secret" and MFA enabled and
+// returns the user with its TOTP secret
+func (e *mfaTestEnv) createMFAUser(t *testing.T, email string) (*model.User, string) {
+	t.Helper()
+	hash, err := e.auth.(*authService).passwordHasher.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "test", AccountName: email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, e.db, email)
+	if err := e.db.Model(user).Updates(map[string]interface{}{"password": hash, "mfa_enabled": true, "mfa_secret": key.Secret()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user, key.Secret()
+}
+
+func TestMFAFailuresCountTowardsLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	env := newMFATestEnv(t)
+	user, secret := env.createMFAUser(t, "mfa@example.com")
+
+	// Every password login starts a fresh challenge, the wrong codes add up anyway
+	for i := 0; i < 3; i++ {
+		if _, err := env.auth.Authenticate(ctx, user.Email, "secret", testClientIP); err != nil {
+			t.Fatalf("Authenticate() attempt %d error = %v", i+1, err)
+		}
+		challenge, err := env.mfa.CreateChallenge(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.mfa.VerifyChallenge(ctx, challenge.MFAToken, "000000", testClientIP); !apperrors.IsUnauthorizedError(err) {
+			t.Fatalf("a wrong code should be rejected, got %v", err)
+		}
+	}
+
+	if _, err := env.auth.Authenticate(ctx, user.Email, "secret", testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Fatalf("the password step should be locked out after wrong codes, got %v", err)
+	}
+	challenge, err := env.mfa.CreateChallenge(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.VerifyChallenge(ctx, challenge.MFAToken, code, testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Errorf("a locked out user should not complete a challenge, got %v", err)
+	}
+}
+
+func TestMFALoginResetsFailuresAfterSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	env := newMFATestEnv(t)
+	user, secret := env.createMFAUser(t, "reset@example.com")
+
+	for i := 0; i < 2; i++ {
+		if _, err := env.auth.Authenticate(ctx, user.Email, "wrong", testClientIP); !apperrors.IsUnauthorizedError(err) {
+			t.Fatalf("a wrong password should be rejected, got %v", err)
+		}
+	}
+
+	// The correct password alone does not reset the failures
+	if _, err := env.auth.Authenticate(ctx, user.Email, "secret", testClientIP); err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := env.mfa.CreateChallenge(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.VerifyChallenge(ctx, challenge.MFAToken, "000000", testClientIP); !apperrors.IsUnauthorizedError(err) {
+		t.Fatal(err)
+	}
+	if err := env.limiter.Check(ctx, user.Email, testClientIP); !apperrors.IsTooManyRequestsError(err) {
+		t.Fatalf("two wrong passwords and a wrong code should lock the user out, got %v", err)
+	}
+
+	if err := env.limiter.Clear(ctx, model.LockoutTypeEmail, user.Email); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.auth.Authenticate(ctx, user.Email, "wrong", testClientIP); !apperrors.IsUnauthorizedError(err) {
+		t.Fatal(err)
+	}
+	challenge, err = env.mfa.CreateChallenge(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.VerifyChallenge(ctx, challenge.MFAToken, code, testClientIP); err != nil {
+		t.Fatalf("VerifyChallenge() error = %v", err)
+	}
+
+	// The completed login starts counting from zero again
+	for i := 0; i < 2; i++ {
+		if _, err := env.auth.Authenticate(ctx, user.Email, "wrong", testClientIP); !apperrors.IsUnauthorizedError(err) {
+			t.Fatalf("attempt %d should not be locked out yet, got %v", i+1, err)
+		}
+	}
+}
+
+func TestMFADisableInvalidatesUserCache(t *testing.T) {
+	ctx := context.Background()
+	env := newMFATestEnv(t)
+	user, secret := env.createMFAUser(t, "cached@example.com")
+
+	cache := newUserCache(env.redis)
+	cached, err := cache.Get(ctx, user.ID, repository.NewUserRepository(env.db).GetByID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.MFAEnabled {
+		t.Fatal("the cached user should have MFA enabled")
+	}
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.mfa.Disable(ctx, user.ID, code); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+
+	exists, err := env.redis.Exists(ctx, cache.key(user.ID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists != 0 {
+		t.Error("disabling MFA should drop the cached user")
+	}
+}
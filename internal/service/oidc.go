@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// OIDCStateTTL is how long a user has to complete the login at the provider
+const OIDCStateTTL = 10 * time.Minute
+
+// OIDCService signs users in through an OpenID Connect provider and links
+// the provider's accounts to local users
+type OIDCService interface {
+	AuthCodeURL(ctx context.Context) (authURL, state string, err error)
+	Complete(ctx context.Context, state, browserState, code string) (*model.User, []string, error)
+}
+
+// oidcStateRecord is stored in Redis for every started login, keyed by the hash of its state
+type oidcStateRecord struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+type oidcService struct {
+	client       *oidc.Client
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	roleService  RoleService
+	tokenService TokenService
+	txManager    repository.TxManager
+	redis        *redis.Client
+	hasher       *password.Hasher
+	config       *config.OIDCConfig
+}
+
+// NewOIDCService creates a new OpenID Connect login service
+func NewOIDCService(client *oidc.Client, identityRepo repository.IdentityRepository, userRepo repository.UserRepository, roleService RoleService, tokenService TokenService, txManager repository.TxManager, redis *redis.Client, hasher *password.Hasher, oidcConfig *config.OIDCConfig) OIDCService {
+	return &oidcService{
+		client:       client,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		roleService:  roleService,
+		tokenService: tokenService,
+		txManager:    txManager,
+		redis:        redis,
+		hasher:       hasher,
+		config:       oidcConfig,
+	}
+}
+
+// AuthCodeURL starts a login and returns the provider URL the user has to be
+// sent to. The returned state has to be kept in the browser, Complete only
+// accepts it from the browser that started the login.
+func (s *oidcService) AuthCodeURL(ctx context.Context) (string, string, error) {
+	if !s.config.Enabled {
+		return "", "", apperrors.NewNotFoundError("oidc login is not enabled")
+	}
+
+	state, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("failed to generate state", err)
+	}
+	nonce, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("failed to generate nonce", err)
+	}
+	verifier, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("failed to generate code verifier", err)
+	}
+
+	authURL, err := s.client.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("identity provider is unavailable", err)
+	}
+
+	recordJSON, err := json.Marshal(&oidcStateRecord{Nonce: nonce, Verifier: verifier})
+	if err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("failed to encode login state", err)
+	}
+	if err := s.redis.Set(ctx, oidcStateKey(hashToken(state)), recordJSON, OIDCStateTTL).Err(); err != nil {
+		return "", "", apperrors.NewInternalErrorWithCause("failed to store login state", err)
+	}
+
+	return authURL, state, nil
+}
+
+// Complete finishes a login with the state and code the provider redirected back
+// with. browserState is the state kept by the browser the callback arrived in;
+// it has to match, so an attacker cannot complete a login they started in
+// someone else's browser. It returns the linked local user and the
+// authentication methods the provider reported, see trustedAMR.
+func (s *oidcService) Complete(ctx context.Context, state, browserState, code string) (*model.User, []string, error) {
+	if !s.config.Enabled {
+		return nil, nil, apperrors.NewNotFoundError("oidc login is not enabled")
+	}
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, nil, apperrors.NewUnauthorizedError("login was not started in this browser")
+	}
+
+	// Every state can be used once
+	cached, err := s.redis.GetDel(ctx, oidcStateKey(hashToken(state))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, apperrors.NewUnauthorizedError("invalid or expired login state")
+	}
+	if err != nil {
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to load login state", err)
+	}
+
+	var record oidcStateRecord
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to decode login state", err)
+	}
+
+	identity, err := s.client.Exchange(ctx, code, record.Nonce, record.Verifier)
+	if err != nil {
+		logger.Warn("OIDC login failed", zap.Error(err))
+		return nil, nil, apperrors.NewUnauthorizedErrorWithCause("identity provider login failed", err)
+	}
+
+	user, err := s.resolveUser(ctx, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, apperrors.NewForbiddenError("account is disabled")
+	}
+
+	return user, s.trustedAMR(identity.AMR), nil
+}
+
+// trustedAMR returns the provider's authentication methods that are taken
+// as they are. Its claim of a second factor is only accepted when configured,
+// otherwise users with local MFA are asked for it and RequireMFA is not met.
+func (s *oidcService) trustedAMR(amr []string) []string {
+	if s.config.TrustProviderMFA {
+		return amr
+	}
+	return slices.DeleteFunc(slices.Clone(amr), func(method string) bool {
+		return method == model.AMRMFA
+	})
+}
+
+// resolveUser returns the user linked to the identity. An unlinked identity is
+// linked to the user with the same verified email, or to a new user if signup is allowed.
+func (s *oidcService) resolveUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	linked, err := s.identityRepo.GetByIssuerSubject(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		if identity.Email != "" && linked.Email != identity.Email {
+			linked.Email = identity.Email
+			if err := s.identityRepo.Update(ctx, linked); err != nil {
+				logger.Warn("Failed to update identity email", zap.Uint("identity_id", linked.ID), zap.Error(err))
+			}
+		}
+
+		// The callback is a GET, a lagging replica could still show a disabled user as active
+		user, err := s.userRepo.GetByID(database.WithPrimary(ctx), linked.UserID)
+		if err != nil {
+			return nil, apperrors.NewUnauthorizedErrorWithCause("linked account not found", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load identity", err)
+	}
+
+	// Linking by email is only safe when the provider vouches for the address
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, apperrors.NewForbiddenError("identity provider did not return a verified email")
+	}
+	if !s.domainAllowed(identity.Email) {
+		return nil, apperrors.NewForbiddenError("email domain is not allowed")
+	}
+
+	// A new or newly verified user is only kept together with the link
+	var user *model.User
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.linkUser(ctx, identity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("OIDC identity linked",
+		zap.Uint("user_id", user.ID),
+		zap.String("issuer", identity.Issuer),
+		zap.String("subject", identity.Subject),
+	)
+	return user, nil
+}
+
+// linkUser links the identity to the user with its email, creating the user
+// if signup is allowed. An account whose email was never verified may have
+// been registered by someone else in advance, so its password is replaced and
+// its sessions are ended before it is handed to the owner of the email.
+func (s *oidcService) linkUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !s.config.AllowSignup {
+			return nil, apperrors.NewForbiddenError("no account exists for this email")
+		}
+		// A deleted account keeps its email until it is purged or restored
+		if _, err := s.userRepo.GetByEmailWithDeleted(ctx, identity.Email); err == nil {
+			return nil, apperrors.NewForbiddenError("account has been deleted")
+		}
+		user, err = s.createUser(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load user", err)
+	} else if user.EmailVerifiedAt == nil {
+		hashedPassword, err := s.randomPasswordHash()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		user.Password = hashedPassword
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to update user", err)
+		}
+		s.clearUserCache(ctx, user.ID)
+
+		if err := s.tokenService.RevokeAllForUser(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		logger.Warn("Unverified account claimed through OIDC, password reset and sessions revoked", zap.Uint("user_id", user.ID))
+	}
+
+	if err := s.identityRepo.Create(ctx, &model.Identity{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	}); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to link identity", err)
+	}
+
+	return user, nil
+}
+
+// createUser signs up a user for the identity. The random password can only be
+// replaced through a password reset, so the user signs in through the provider.
+func (s *oidcService) createUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	hashedPassword, err := s.randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Name:            name,
+		Email:           identity.Email,
+		Password:        hashedPassword,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to create user", err)
+	}
+
+	// The user exists at this point, a missing role only limits what they can do
+	if err := s.roleService.AssignDefaultRoles(ctx, user); err != nil {
+		logger.Warn("Failed to assign default roles", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	s.clearUserCache(ctx, user.ID)
+	logger.Info("User signed up through OIDC", zap.Uint("user_id", user.ID))
+	return user, nil
+}
+
+// randomPasswordHash returns the hash of a random password nobody knows
+func (s *oidcService) randomPasswordHash() (string, error) {
+	randomPassword, err := generateRandomToken(32)
+	if err != nil {
+		return "", apperrors.NewInternalErrorWithCause("failed to generate password", err)
+	}
+	hashedPassword, err := s.hasher.Hash(randomPassword)
+	if err != nil {
+		return "", apperrors.NewInternalErrorWithCause("failed to hash password", err)
+	}
+	return hashedPassword, nil
+}
+
+// clearUserCache drops the cached user and user lists
+func (s *oidcService) clearUserCache(ctx context.Context, userID uint) {
+	newUserCache(s.redis).Invalidate(ctx, userID)
+}
+
+func (s *oidcService) domainAllowed(email string) bool {
+	if len(s.config.AllowedDomains) == 0 {
+		return true
+	}
+
+	_, domain, _ := strings.Cut(email, "@")
+	for _, allowed := range s.config.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func oidcStateKey(stateHash string) string {
+	return fmt.Sprintf("oidc_state:%s", stateHash)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc/oidctest"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"gorm.io/gorm"
+)
+
+const testRedirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+
+type oidcTestEnv struct {
+	svc      OIDCService
+	provider *oidctest.Provider
+	db       *gorm.DB
+	tokens   TokenService
+	config   *config.OIDCConfig
+}
+
+func newOIDCTestEnv(t *testing.T, user oidctest.User) *oidcTestEnv {
+	t.Helper()
+	provider, err := oidctest.NewProvider("test-client", "test-secret", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(provider.Close)
+
+	db := newTestDB(t)
+	client, _ := newTestRedis(t)
+	hasher, err := password.NewHasher(&config.PasswordConfig{Algorithm: password.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.OIDCConfig{
+		Enabled:      true,
+		Issuer:       provider.Issuer(),
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  testRedirectURL,
+		AllowSignup:  true,
+	}
+	userRepo := repository.NewUserRepository(db)
+	tokens := NewTokenService(userRepo, repository.NewRoleRepository(db), client, newTestJWTConfig())
+	return &oidcTestEnv{
+		svc:      NewOIDCService(oidc.New(cfg), repository.NewIdentityRepository(db), userRepo, newTestRoleService(t, db, ""), tokens, repository.NewTxManager(db), client, hasher, cfg),
+		provider: provider,
+		db:       db,
+		tokens:   tokens,
+		config:   cfg,
+	}
+}
+
+// startLogin starts a login and follows it through the provider, returning
+// the state kept by the browser and the state and code of the callback
+func (e *oidcTestEnv) startLogin(t *testing.T) (browserState, state, code string) {
+	t.Helper()
+	authURL, browserState, err := e.svc.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	callback, err := e.provider.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return browserState, callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+func TestOIDCCompleteRequiresBrowserState(t *testing.T) {
+	ctx := context.Background()
+	env := newOIDCTestEnv(t, oidctest.User{Subject: "provider-sub", Email: "attacker@example.com", EmailVerified: true})
+
+	// The attacker starts a login and sends the callback URL to someone else
+	_, state, code := env.startLogin(t)
+	otherBrowserState, _, _ := env.startLogin(t)
+
+	if _, _, err := env.svc.Complete(ctx, state, "", code); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("a callback without state cookie should be rejected, got %v", err)
+	}
+	if _, _, err := env.svc.Complete(ctx, state, otherBrowserState, code); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("a callback with the state of another login should be rejected, got %v", err)
+	}
+
+	browserState, state, code := env.startLogin(t)
+	user, _, err := env.svc.Complete(ctx, state, browserState, code)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if user.Email != "attacker@example.com" {
+		t.Errorf("unexpected user %q", user.Email)
+	}
+}
+
+func TestOIDCClaimsUnverifiedAccount(t *testing.T) {
+	ctx := context.Background()
+	env := newOIDCTestEnv(t, oidctest.User{Subject: "owner-sub", Email: "owner@example.com", EmailVerified: true})
+
+	// Someone registered the address before its owner and is still signed in
+	squatter := createTestUser(t, env.db, "owner@example.com")
+	pair, err := env.tokens.IssueTokenPair(ctx, squatter, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	browserState, state, code := env.startLogin(t)
+	user, _, err := env.svc.Complete(ctx, state, browserState, code)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if user.ID != squatter.ID || user.EmailVerifiedAt == nil {
+		t.Fatalf("the account should be linked and verified: %+v", user)
+	}
+
+	stored, err := repository.NewUserRepository(env.db).GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password == squatter.Password {
+		t.Error("the password chosen before the email was verified should be replaced")
+	}
+	if _, err := env.tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("refresh tokens issued before the link should be revoked, got %v", err)
+	}
+	if err := env.tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), pair.AccessToken)); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("access tokens issued before the link should be revoked, got %v", err)
+	}
+}
+
+func TestOIDCProviderMFA(t *testing.T) {
+	ctx := context.Background()
+	env := newOIDCTestEnv(t, oidctest.User{Subject: "mfa-sub", Email: "mfa@example.com", EmailVerified: true, AMR: []string{"pwd", "mfa"}})
+
+	browserState, state, code := env.startLogin(t)
+	_, amr, err := env.svc.Complete(ctx, state, browserState, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(amr, model.AMRMFA) {
+		t.Errorf("the provider's mfa should not be trusted by default, got amr %v", amr)
+	}
+
+	env.config.TrustProviderMFA = true
+	browserState, state, code = env.startLogin(t)
+	_, amr, err = env.svc.Complete(ctx, state, browserState, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(amr, model.AMRMFA) {
+		t.Errorf("the provider's mfa should be trusted when configured, got amr %v", amr)
+	}
+}
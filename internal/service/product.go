@@ -2,37 +2,42 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"time"
+	"errors"
 
 	"github.com/IndigoCloud6/go-web-template/internal/model"
 	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
 	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
 	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // ProductService handles business logic for products
 type ProductService interface {
 	Create(ctx context.Context, req *model.CreateProductRequest) (*model.Product, error)
 	GetByID(ctx context.Context, id uint) (*model.Product, error)
-	List(ctx context.Context, page, pageSize int) ([]*model.Product, int64, error)
+	List(ctx context.Context, req *model.ListProductsRequest) ([]*model.Product, *query.PageInfo, error)
 	Update(ctx context.Context, id uint, req *model.UpdateProductRequest) (*model.Product, error)
 	Delete(ctx context.Context, id uint) error
+	ListDeleted(ctx context.Context, page, pageSize int) ([]*model.Product, int64, error)
+	Restore(ctx context.Context, id uint) (*model.Product, error)
 }
 
 type productService struct {
-	repo  repository.ProductRepository
-	redis *redis.Client
+	repo    repository.ProductRepository
+	cache   *entityCache[model.Product, uint]
+	cursors *query.CursorCodec
 }
 
 // NewProductService creates a new product service
-func NewProductService(repo repository.ProductRepository, redis *redis.Client) ProductService {
+func NewProductService(repo repository.ProductRepository, redis *redis.Client, cursors *query.CursorCodec) ProductService {
 	return &productService{
-		repo:  repo,
-		redis: redis,
+		repo:    repo,
+		cache:   newEntityCache[model.Product, uint](redis, "product", "products"),
+		cursors: cursors,
 	}
 }
 
@@ -50,74 +55,67 @@ func (s *productService) Create(ctx context.Context, req *model.CreateProductReq
 	}
 
 	// Clear product list cache
-	keys, _ := s.redis.Keys(ctx, "products:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
-	}
+	s.cache.InvalidateLists(ctx)
 
 	return product, nil
 }
 
 // GetByID retrieves a product by ID with caching
 func (s *productService) GetByID(ctx context.Context, id uint) (*model.Product, error) {
-	cacheKey := fmt.Sprintf("product:%d", id)
-
-	// Try to get from cache
-	cached, err := s.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var product model.Product
-		if err := json.Unmarshal([]byte(cached), &product); err == nil {
-			return &product, nil
-		}
-	}
-
-	// Get from database
-	product, err := s.repo.GetByID(ctx, id)
+	product, err := s.cache.Get(ctx, id, s.repo.GetByID)
 	if err != nil {
 		return nil, apperrors.NewNotFoundErrorWithCause("product not found", err)
 	}
-
-	// Cache the result
-	productJSON, err := json.Marshal(product)
-	if err != nil {
-		logger.Warn("Failed to marshal product for caching", zap.Error(err))
-	} else {
-		s.redis.Set(ctx, cacheKey, productJSON, 5*time.Minute)
-	}
-
 	return product, nil
 }
 
-// List retrieves a list of products with pagination
-func (s *productService) List(ctx context.Context, page, pageSize int) ([]*model.Product, int64, error) {
-	if page < 1 {
-		page = 1
+// List retrieves a page of the products matching the request's filter. Pages
+// are numbered, or follow a cursor of a previous page. The page and page size
+// in req are normalized to the values used.
+func (s *productService) List(ctx context.Context, req *model.ListProductsRequest) ([]*model.Product, *query.PageInfo, error) {
+	if req.Page < 1 {
+		req.Page = 1
 	}
-	if pageSize < 1 {
-		pageSize = 10
+	if req.PageSize < 1 {
+		req.PageSize = 10
 	}
-	if pageSize > 100 {
-		pageSize = 100
+	if req.PageSize > 100 {
+		req.PageSize = 100
 	}
 
-	offset := (page - 1) * pageSize
-
-	products, err := s.repo.List(ctx, offset, pageSize)
+	sorts, err := query.ParseSort(req.Sort, model.ProductSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, err := s.cursors.NewPage(req.Cursor, sorts, req.Page, req.PageSize)
 	if err != nil {
-		return nil, 0, apperrors.NewInternalErrorWithCause("failed to list products", err)
+		return nil, nil, err
 	}
 
-	total, err := s.repo.Count(ctx)
+	products, err := s.repo.List(ctx, &req.ProductFilter, page)
+	if err != nil {
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to list products", err)
+	}
+	products, info, err := query.BuildPage(s.cursors, page, products)
 	if err != nil {
-		return nil, 0, apperrors.NewInternalErrorWithCause("failed to count products", err)
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to create cursors", err)
 	}
 
-	return products, total, nil
+	// Counting is optional for cursor pages, it scans every matching row
+	if count := req.Count; (count == nil && req.Cursor == "") || (count != nil && *count) {
+		total, err := s.repo.Count(ctx, &req.ProductFilter)
+		if err != nil {
+			return nil, nil, apperrors.NewInternalErrorWithCause("failed to count products", err)
+		}
+		info.Total = &total
+	}
+
+	return products, info, nil
 }
 
 // Update updates a product
 func (s *productService) Update(ctx context.Context, id uint, req *model.UpdateProductRequest) (*model.Product, error) {
-	product, err := s.repo.GetByID(ctx, id)
+	product, err := s.repo.GetByID(database.WithPrimary(ctx), id)
 	if err != nil {
 		return nil, apperrors.NewNotFoundErrorWithCause("product not found", err)
 	}
@@ -141,14 +139,7 @@ func (s *productService) Update(ctx context.Context, id uint, req *model.UpdateP
 	}
 
 	// Clear cache
-	cacheKey := fmt.Sprintf("product:%d", id)
-	s.redis.Del(ctx, cacheKey)
-
-	// Clear product list cache
-	keys, _ := s.redis.Keys(ctx, "products:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
-	}
+	s.cache.Invalidate(ctx, id)
 
 	return product, nil
 }
@@ -156,7 +147,7 @@ func (s *productService) Update(ctx context.Context, id uint, req *model.UpdateP
 // Delete deletes a product
 func (s *productService) Delete(ctx context.Context, id uint) error {
 	// Check if product exists
-	_, err := s.repo.GetByID(ctx, id)
+	_, err := s.repo.GetByID(database.WithPrimary(ctx), id)
 	if err != nil {
 		return apperrors.NewNotFoundErrorWithCause("product not found", err)
 	}
@@ -166,14 +157,55 @@ func (s *productService) Delete(ctx context.Context, id uint) error {
 	}
 
 	// Clear cache
-	cacheKey := fmt.Sprintf("product:%d", id)
-	s.redis.Del(ctx, cacheKey)
+	s.cache.Invalidate(ctx, id)
 
-	// Clear product list cache
-	keys, _ := s.redis.Keys(ctx, "products:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
+	return nil
+}
+
+// ListDeleted retrieves products in the trash with pagination
+func (s *productService) ListDeleted(ctx context.Context, page, pageSize int) ([]*model.Product, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
 	}
 
-	return nil
+	offset := (page - 1) * pageSize
+
+	products, err := s.repo.ListDeleted(ctx, offset, pageSize)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to list deleted products", err)
+	}
+
+	total, err := s.repo.CountDeleted(ctx)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to count deleted products", err)
+	}
+
+	return products, total, nil
+}
+
+// Restore takes a product out of the trash
+func (s *productService) Restore(ctx context.Context, id uint) (*model.Product, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFoundError("deleted product not found")
+		}
+		return nil, apperrors.NewInternalErrorWithCause("failed to restore product", err)
+	}
+
+	product, err := s.repo.GetByID(database.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load product", err)
+	}
+
+	// Clear product list cache
+	s.cache.InvalidateLists(ctx)
+
+	logger.Info("Product restored", zap.Uint("product_id", id))
+	return product, nil
 }
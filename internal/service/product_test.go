@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
+	"gorm.io/gorm"
+)
+
+func newTestProductService(t *testing.T) (ProductService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	client, _ := newTestRedis(t)
+	return NewProductService(repository.NewProductRepository(db), client, query.NewCursorCodec("test-cursor-secret")), db
+}
+
+// createTestProduct stores a product with the given name
+func createTestProduct(t *testing.T, db *gorm.DB, name string) *model.Product {
+	t.Helper()
+	product := &model.Product{Name: name, Price: 9.99, Stock: 1}
+	if err := repository.NewProductRepository(db).Create(context.Background(), product); err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+func TestProductDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	products, db := newTestProductService(t)
+	product := createTestProduct(t, db, "Lamp")
+
+	if err := products.Delete(ctx, product.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := products.GetByID(ctx, product.ID); !apperrors.IsNotFoundError(err) {
+		t.Errorf("a deleted product should not be found, got %v", err)
+	}
+	if err := products.Delete(ctx, product.ID); !apperrors.IsNotFoundError(err) {
+		t.Errorf("deleting a product twice should not find it, got %v", err)
+	}
+
+	trash, total, err := products.ListDeleted(ctx, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(trash) != 1 || trash[0].ID != product.ID || !trash[0].DeletedAt.Valid {
+		t.Fatalf("the product should be in the trash, got %d of %d", len(trash), total)
+	}
+
+	restored, err := products.Restore(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.DeletedAt.Valid {
+		t.Error("a restored product should not be marked deleted")
+	}
+	if _, err := products.GetByID(ctx, product.ID); err != nil {
+		t.Errorf("a restored product should be found: %v", err)
+	}
+	if _, err := products.Restore(ctx, product.ID); !apperrors.IsNotFoundError(err) {
+		t.Errorf("restoring a product that is not in the trash should not find it, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// PurgeService permanently removes users and products that have been in the
+// trash for longer than the retention period
+type PurgeService interface {
+	Purge(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+type purgeService struct {
+	userRepo    repository.UserRepository
+	productRepo repository.ProductRepository
+	config      *config.TrashConfig
+}
+
+// NewPurgeService creates a new purge service
+func NewPurgeService(userRepo repository.UserRepository, productRepo repository.ProductRepository, trashConfig *config.TrashConfig) PurgeService {
+	return &purgeService{
+		userRepo:    userRepo,
+		productRepo: productRepo,
+		config:      trashConfig,
+	}
+}
+
+// Purge removes the records deleted before the retention period
+func (s *purgeService) Purge(ctx context.Context) error {
+	before := time.Now().Add(-s.retention())
+
+	users, err := s.userRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to purge deleted users", err)
+	}
+
+	products, err := s.productRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to purge deleted products", err)
+	}
+
+	if users > 0 || products > 0 {
+		logger.Info("Purged deleted records", zap.Int64("users", users), zap.Int64("products", products))
+	}
+	return nil
+}
+
+// Run purges once right away and then on every interval until ctx is cancelled
+func (s *purgeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval())
+	defer ticker.Stop()
+
+	for {
+		if err := s.Purge(ctx); err != nil {
+			logger.Error("Failed to purge deleted records", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *purgeService) retention() time.Duration {
+	days := s.config.RetentionDays
+	if days <= 0 {
+		days = 30 // default to 30 days
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (s *purgeService) interval() time.Duration {
+	minutes := s.config.PurgeIntervalMinutes
+	if minutes <= 0 {
+		minutes = 60 // default to 1 hour
+	}
+	return time.Duration(minutes) * time.Minute
+}
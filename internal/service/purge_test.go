@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"gorm.io/gorm"
+)
+
+// deleteAt soft deletes a row with the given deletion time
+func deleteAt(t *testing.T, db *gorm.DB, row interface{}, at time.Time) {
+	t.Helper()
+	if err := db.Model(row).Update("deleted_at", at).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countUnscoped(t *testing.T, db *gorm.DB, value interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Unscoped().Model(value).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestPurgeRemovesExpiredTrash(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	purge := NewPurgeService(userRepo, repository.NewProductRepository(db), &config.TrashConfig{RetentionDays: 30})
+
+	expiredUser := createTestUser(t, db, "expired@example.com")
+	recentUser := createTestUser(t, db, "recent@example.com")
+	activeUser := createTestUser(t, db, "active@example.com")
+	expiredProduct := createTestProduct(t, db, "Expired")
+	recentProduct := createTestProduct(t, db, "Recent")
+
+	// Rows depending on the expired user go with it
+	roles := newTestRoleService(t, db, "")
+	if err := roles.AssignRole(ctx, expiredUser.ID, model.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.APIKey{UserID: expiredUser.ID, Name: "ci", Prefix: "expired", KeyHash: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleteAt(t, db, expiredUser, time.Now().AddDate(0, 0, -31))
+	deleteAt(t, db, recentUser, time.Now().AddDate(0, 0, -1))
+	deleteAt(t, db, expiredProduct, time.Now().AddDate(0, 0, -31))
+	deleteAt(t, db, recentProduct, time.Now().AddDate(0, 0, -1))
+
+	if err := purge.Purge(ctx); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	if n := countUnscoped(t, db, &model.User{}); n != 2 {
+		t.Errorf("expected the recently deleted and the active user to remain, got %d users", n)
+	}
+	if _, err := userRepo.GetByEmailWithDeleted(ctx, expiredUser.Email); err == nil {
+		t.Error("the user deleted before the retention period should be purged")
+	}
+	if _, err := userRepo.GetByID(ctx, activeUser.ID); err != nil {
+		t.Errorf("an active user should not be purged: %v", err)
+	}
+	if n := countUnscoped(t, db, &model.Product{}); n != 1 {
+		t.Errorf("expected only the recently deleted product to remain, got %d products", n)
+	}
+
+	var roleAssignments, apiKeys int64
+	db.Table("user_roles").Where("user_id = ?", expiredUser.ID).Count(&roleAssignments)
+	db.Model(&model.APIKey{}).Where("user_id = ?", expiredUser.ID).Count(&apiKeys)
+	if roleAssignments != 0 || apiKeys != 0 {
+		t.Errorf("dependent rows of a purged user should be removed, got %d roles and %d API keys", roleAssignments, apiKeys)
+	}
+}
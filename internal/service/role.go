@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// defaultRoles lists the built-in roles and the permissions they grant
+var defaultRoles = []struct {
+	Name        string
+	Description string
+	Permissions []string
+}{
+	{
+		Name:        model.RoleAdmin,
+		Description: "Full access to all resources",
+		Permissions: []string{
+			model.PermissionUsersRead,
+			model.PermissionUsersWrite,
+			model.PermissionProductsWrite,
+			model.PermissionRolesManage,
+		},
+	},
+	{
+		Name:        model.RoleUser,
+		Description: "Default role for registered users",
+	},
+}
+
+// RoleService handles business logic for roles and permissions
+type RoleService interface {
+	List(ctx context.Context) ([]*model.Role, error)
+	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	AssignRole(ctx context.Context, userID uint, roleName string) error
+	RevokeRole(ctx context.Context, userID uint, roleName string) error
+	AssignDefaultRoles(ctx context.Context, user *model.User) error
+	SeedDefaults(ctx context.Context) error
+	BootstrapAdmin(ctx context.Context) error
+}
+
+type roleService struct {
+	repo         repository.RoleRepository
+	userRepo     repository.UserRepository
+	tokenService TokenService
+	txManager    repository.TxManager
+	rbacConfig   *config.RBACConfig
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(repo repository.RoleRepository, userRepo repository.UserRepository, tokenService TokenService, txManager repository.TxManager, rbacConfig *config.RBACConfig) RoleService {
+	return &roleService{
+		repo:         repo,
+		userRepo:     userRepo,
+		tokenService: tokenService,
+		txManager:    txManager,
+		rbacConfig:   rbacConfig,
+	}
+}
+
+// List retrieves all roles with their permissions
+func (s *roleService) List(ctx context.Context) ([]*model.Role, error) {
+	roles, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to list roles", err)
+	}
+	return roles, nil
+}
+
+// GetUserRoles retrieves the roles assigned to a user
+func (s *roleService) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to get user roles", err)
+	}
+	return roles, nil
+}
+
+// AssignRole assigns a role to a user. The new role shows up in the user's next access token.
+func (s *roleService) AssignRole(ctx context.Context, userID uint, roleName string) error {
+	if _, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID); err != nil {
+		return apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AssignToUser(ctx, userID, role); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to assign role", err)
+	}
+	return nil
+}
+
+// RevokeRole removes a role from a user and revokes the user's tokens,
+// since they still carry the role
+func (s *roleService) RevokeRole(ctx context.Context, userID uint, roleName string) error {
+	if _, err := s.userRepo.GetByID(database.WithPrimary(ctx), userID); err != nil {
+		return apperrors.NewNotFoundErrorWithCause("user not found", err)
+	}
+
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	// Never lock everyone out of the admin endpoints
+	if role.Name == model.RoleAdmin {
+		count, err := s.repo.CountUsers(ctx, role)
+		if err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to count admins", err)
+		}
+		if count <= 1 {
+			return apperrors.NewConflictError("cannot revoke the last admin")
+		}
+	}
+
+	if err := s.repo.RevokeFromUser(ctx, userID, role); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke role", err)
+	}
+
+	return s.tokenService.RevokeAllForUser(ctx, userID)
+}
+
+// AssignDefaultRoles assigns the default role to a newly created user.
+// The user whose email matches rbac.bootstrap_admin_email also becomes admin
+// as long as no admin exists yet and the email is verified, e.g. by the
+// identity provider.
+func (s *roleService) AssignDefaultRoles(ctx context.Context, user *model.User) error {
+	if err := s.AssignRole(ctx, user.ID, model.RoleUser); err != nil {
+		return err
+	}
+
+	if s.rbacConfig.BootstrapAdminEmail != "" && user.Email == s.rbacConfig.BootstrapAdminEmail {
+		return s.BootstrapAdmin(ctx)
+	}
+	return nil
+}
+
+// SeedDefaults creates the built-in roles and permissions if they do not exist,
+// in one transaction so a failed seed leaves no partial role behind
+func (s *roleService) SeedDefaults(ctx context.Context) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, def := range defaultRoles {
+			role := &model.Role{Name: def.Name, Description: def.Description}
+			if err := s.repo.FirstOrCreate(ctx, role); err != nil {
+				return apperrors.NewInternalErrorWithCause("failed to seed role", err)
+			}
+
+			permissions := make([]*model.Permission, 0, len(def.Permissions))
+			for _, name := range def.Permissions {
+				permission := &model.Permission{Name: name}
+				if err := s.repo.FirstOrCreatePermission(ctx, permission); err != nil {
+					return apperrors.NewInternalErrorWithCause("failed to seed permission", err)
+				}
+				permissions = append(permissions, permission)
+			}
+
+			if len(permissions) > 0 {
+				if err := s.repo.SetPermissions(ctx, role, permissions); err != nil {
+					return apperrors.NewInternalErrorWithCause("failed to seed role permissions", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// BootstrapAdmin grants the admin role to the user configured in rbac.bootstrap_admin_email
+// if there is no admin yet. It is a no-op when the option is empty, the user does not
+// exist or has not verified the email yet, since anyone can sign up with any address.
+func (s *roleService) BootstrapAdmin(ctx context.Context) error {
+	email := s.rbacConfig.BootstrapAdminEmail
+	if email == "" {
+		return nil
+	}
+
+	role, err := s.getRole(ctx, model.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountUsers(ctx, role)
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to count admins", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load bootstrap admin", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		logger.Warn("Bootstrap admin has not verified the email yet", zap.Uint("user_id", user.ID), zap.String("email", email))
+		return nil
+	}
+
+	if err := s.repo.AssignToUser(ctx, user.ID, role); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to assign admin role", err)
+	}
+
+	logger.Info("Bootstrapped first admin", zap.Uint("user_id", user.ID), zap.String("email", email))
+	return nil
+}
+
+func (s *roleService) getRole(ctx context.Context, name string) (*model.Role, error) {
+	role, err := s.repo.GetByName(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.NewNotFoundErrorWithCause("role not found", err)
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load role", err)
+	}
+	return role, nil
+}
+
+// roleClaims returns the role names and the union of their permissions, both sorted
+func roleClaims(roles []*model.Role) ([]string, []string) {
+	names := make([]string, 0, len(roles))
+	seen := make(map[string]bool)
+	var permissions []string
+
+	for _, role := range roles {
+		names = append(names, role.Name)
+		for _, p := range role.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				permissions = append(permissions, p.Name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	sort.Strings(permissions)
+	return names, permissions
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func newTestRoleService(t *testing.T, db *gorm.DB, bootstrapEmail string) RoleService {
+	t.Helper()
+	client, _ := newTestRedis(t)
+	userRepo := repository.NewUserRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	tokens := NewTokenService(userRepo, roleRepo, client, newTestJWTConfig())
+	roles := NewRoleService(roleRepo, userRepo, tokens, repository.NewTxManager(db), &config.RBACConfig{BootstrapAdminEmail: bootstrapEmail})
+	if err := roles.SeedDefaults(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return roles
+}
+
+func hasRole(t *testing.T, roles RoleService, userID uint, name string) bool {
+	t.Helper()
+	userRoles, err := roles.GetUserRoles(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range userRoles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBootstrapAdminRequiresVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	roles := newTestRoleService(t, db, "admin@example.com")
+
+	// Anyone can sign up with the address, that alone must not grant admin
+	user := createTestUser(t, db, "admin@example.com")
+	if err := roles.AssignDefaultRoles(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if hasRole(t, roles, user.ID, model.RoleAdmin) {
+		t.Fatal("an unverified bootstrap email should not become admin")
+	}
+
+	now := time.Now()
+	if err := db.Model(user).Update("email_verified_at", &now).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := roles.BootstrapAdmin(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !hasRole(t, roles, user.ID, model.RoleAdmin) {
+		t.Error("the verified bootstrap email should become admin")
+	}
+}
+
+func TestRevokeLastAdminIgnoresDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	roles := newTestRoleService(t, db, "")
+
+	admin := createTestUser(t, db, "admin@example.com")
+	deleted := createTestUser(t, db, "deleted@example.com")
+	for _, user := range []*model.User{admin, deleted} {
+		if err := roles.AssignRole(ctx, user.ID, model.RoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repository.NewUserRepository(db).Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := roles.RevokeRole(ctx, admin.ID, model.RoleAdmin); !apperrors.IsConflictError(err) {
+		t.Errorf("an admin in the trash should not count, revoking the last active admin returned %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionTouchInterval limits how often last_seen_at is written for an active session
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength caps the user agent stored with a session
+	maxUserAgentLength = 512
+)
+
+// touchSessionScript updates a field of a session only while the session
+// exists, so a request racing with a revocation cannot bring it back
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+end
+return 0
+`)
+
+// sessionRecord is the Redis hash stored for each session. A session is a
+// refresh token family, so its ID is the family ID.
+type sessionRecord struct {
+	UserID     uint   `redis:"user_id"`
+	Device     string `redis:"device"`
+	UserAgent  string `redis:"user_agent"`
+	IP         string `redis:"ip"`
+	CreatedAt  int64  `redis:"created_at"`
+	LastSeenAt int64  `redis:"last_seen_at"`
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *tokenService) ListSessions(ctx context.Context, userID uint) ([]*model.Session, error) {
+	ids, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load sessions", err)
+	}
+
+	pipe := s.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, sessionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load sessions", err)
+	}
+
+	sessions := []*model.Session{}
+	var expired []interface{}
+	for i, cmd := range cmds {
+		var record sessionRecord
+		if len(cmd.Val()) == 0 {
+			expired = append(expired, ids[i])
+			continue
+		}
+		if err := cmd.Scan(&record); err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to decode session", err)
+		}
+		if record.UserID != userID {
+			continue
+		}
+
+		sessions = append(sessions, &model.Session{
+			ID:         ids[i],
+			Device:     record.Device,
+			UserAgent:  record.UserAgent,
+			IP:         record.IP,
+			CreatedAt:  time.Unix(record.CreatedAt, 0),
+			LastSeenAt: time.Unix(record.LastSeenAt, 0),
+		})
+	}
+
+	// Sessions that expired on their own are still listed in the user's set
+	if len(expired) > 0 {
+		if err := s.redis.SRem(ctx, userSessionsKey(userID), expired...).Err(); err != nil {
+			logger.Warn("Failed to clean up expired sessions", zap.Uint("user_id", userID), zap.Error(err))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions. Its refresh tokens are deleted
+// and its access tokens are rejected from the next request on.
+func (s *tokenService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	owner, err := s.redis.HGet(ctx, sessionKey(sessionID), "user_id").Uint64()
+	if errors.Is(err, redis.Nil) || (err == nil && uint(owner) != userID) {
+		return apperrors.NewNotFoundError("session not found")
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load session", err)
+	}
+
+	if err := s.revokeFamily(ctx, sessionID); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke session", err)
+	}
+	if err := s.redis.SRem(ctx, userSessionsKey(userID), sessionID).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke session", err)
+	}
+
+	logger.Info("Session revoked", zap.Uint("user_id", userID), zap.String("session_id", sessionID))
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the user except keepSessionID.
+// Unlike RevokeAllForUser it leaves the tokens of the kept session valid.
+func (s *tokenService) RevokeOtherSessions(ctx context.Context, userID uint, keepSessionID string) error {
+	ids, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load sessions", err)
+	}
+
+	var revoked []interface{}
+	for _, id := range ids {
+		if id == keepSessionID {
+			continue
+		}
+		if err := s.revokeFamily(ctx, id); err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to revoke sessions", err)
+		}
+		revoked = append(revoked, id)
+	}
+	if len(revoked) > 0 {
+		if err := s.redis.SRem(ctx, userSessionsKey(userID), revoked...).Err(); err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to revoke sessions", err)
+		}
+	}
+
+	logger.Info("Other sessions revoked", zap.Uint("user_id", userID), zap.Int("count", len(revoked)))
+	return nil
+}
+
+// recordSession creates the session on login and updates the client details and
+// last use on every refresh, extending it for another refresh token lifetime
+func (s *tokenService) recordSession(ctx context.Context, userID uint, sessionID string, client *model.ClientInfo) error {
+	now := time.Now().Unix()
+	key := sessionKey(sessionID)
+	ttl := s.sessionTTL()
+
+	values := []interface{}{"last_seen_at", now}
+	if client != nil {
+		userAgent := client.UserAgent
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		values = append(values,
+			"user_agent", userAgent,
+			"device", describeDevice(userAgent),
+			"ip", client.IP,
+		)
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSetNX(ctx, key, "user_id", userID)
+	pipe.HSetNX(ctx, key, "created_at", now)
+	pipe.HSet(ctx, key, values...)
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// touchSession records that the session was used, at most once per sessionTouchInterval
+func (s *tokenService) touchSession(ctx context.Context, sessionID string, lastSeenAt int64) {
+	now := time.Now()
+	if now.Sub(time.Unix(lastSeenAt, 0)) < sessionTouchInterval {
+		return
+	}
+
+	if err := touchSessionScript.Run(ctx, s.redis, []string{sessionKey(sessionID)}, "last_seen_at", now.Unix()).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		logger.Warn("Failed to record session activity", zap.String("session_id", sessionID), zap.Error(err))
+	}
+}
+
+// sessionTTL keeps a session at least as long as the tokens issued in it
+func (s *tokenService) sessionTTL() time.Duration {
+	ttl := s.refreshTTL()
+	if accessTTL := middleware.AccessTokenTTL(s.jwtConfig); accessTTL > ttl {
+		ttl = accessTTL
+	}
+	return ttl
+}
+
+// describeDevice derives a short, human readable device description such as
+// "Chrome on macOS" from a user agent
+func describeDevice(userAgent string) string {
+	var browser string
+	switch {
+	case userAgent == "":
+		return "Unknown device"
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	default:
+		// Non-browser clients such as curl/8.4.0 or okhttp/4.12.0
+		browser, _, _ = strings.Cut(userAgent, "/")
+		browser, _, _ = strings.Cut(browser, " ")
+	}
+
+	var platform string
+	switch {
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		platform = "iOS"
+	case strings.Contains(userAgent, "Mac OS X"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
+	}
+
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
+
+func sessionKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+func userSessionsKey(userID uint) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+func TestListSessions(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "list@example.com")
+	other := createTestUser(t, db, "other@example.com")
+
+	client := &model.ClientInfo{
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		IP:        testClientIP,
+	}
+	pair, err := tokens.IssueTokenPair(ctx, user, nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.IssueTokenPair(ctx, user, nil, &model.ClientInfo{UserAgent: "curl/8.4.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.IssueTokenPair(ctx, other, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := tokens.ListSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	sessionID := parseTestToken(t, newTestJWTConfig(), pair.AccessToken).SessionID
+	devices := map[string]string{}
+	for _, session := range sessions {
+		devices[session.ID] = session.Device
+	}
+	if devices[sessionID] != "Chrome on Windows" {
+		t.Errorf("unexpected device of the browser session: %q", devices[sessionID])
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "session@example.com")
+
+	revoked, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := parseTestToken(t, newTestJWTConfig(), revoked.AccessToken)
+
+	if err := tokens.RevokeSession(ctx, user.ID, claims.SessionID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+
+	if err := tokens.ValidateToken(ctx, claims); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("access tokens of a revoked session should be rejected, got %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, revoked.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("refresh tokens of a revoked session should be rejected, got %v", err)
+	}
+	if err := tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), kept.AccessToken)); err != nil {
+		t.Errorf("other sessions should stay valid: %v", err)
+	}
+
+	sessions, err := tokens.ListSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID == claims.SessionID {
+		t.Errorf("the revoked session should not be listed: %+v", sessions)
+	}
+}
+
+func TestRevokeSessionOfAnotherUser(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	owner := createTestUser(t, db, "owner@example.com")
+	attacker := createTestUser(t, db, "attacker@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, owner, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := parseTestToken(t, newTestJWTConfig(), pair.AccessToken)
+
+	if err := tokens.RevokeSession(ctx, attacker.ID, claims.SessionID); !apperrors.IsNotFoundError(err) {
+		t.Errorf("revoking a session of another user should not find it, got %v", err)
+	}
+	if err := tokens.ValidateToken(ctx, claims); err != nil {
+		t.Errorf("the session should stay valid: %v", err)
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "others@example.com")
+
+	current, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var others []*model.TokenPair
+	for i := 0; i < 2; i++ {
+		pair, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		others = append(others, pair)
+	}
+	currentClaims := parseTestToken(t, newTestJWTConfig(), current.AccessToken)
+
+	if err := tokens.RevokeOtherSessions(ctx, user.ID, currentClaims.SessionID); err != nil {
+		t.Fatalf("RevokeOtherSessions() error = %v", err)
+	}
+
+	for _, pair := range others {
+		if err := tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), pair.AccessToken)); !apperrors.IsUnauthorizedError(err) {
+			t.Errorf("access tokens of other sessions should be rejected, got %v", err)
+		}
+		if _, err := tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+			t.Errorf("refresh tokens of other sessions should be rejected, got %v", err)
+		}
+	}
+
+	if err := tokens.ValidateToken(ctx, currentClaims); err != nil {
+		t.Errorf("the current session should stay valid: %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, current.RefreshToken, nil); err != nil {
+		t.Errorf("the current session should keep refreshing: %v", err)
+	}
+	sessions, err := tokens.ListSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != currentClaims.SessionID {
+		t.Errorf("only the current session should be listed: %+v", sessions)
+	}
+}
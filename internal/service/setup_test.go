@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// newTestDB returns an in-memory SQLite database with the schema of all models
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.New(&config.DatabaseConfig{Driver: database.DriverSQLite, Path: database.SQLiteMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	if err := db.AutoMigrate(&model.User{}, &model.Product{}, &model.Role{}, &model.Permission{}, &model.RecoveryCode{}, &model.APIKey{}, &model.Identity{}, &model.AuditLog{}, &model.OAuthClient{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestRedis returns a client of a Redis server running in memory
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func newTestJWTConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		Secret:                  "test-secret-key",
+		Issuer:                  "test-issuer",
+		AccessExpirationMinutes: 15,
+		RefreshExpirationHours:  24,
+	}
+}
+
+// createTestUser stores a user with the given email
+func createTestUser(t *testing.T, db *gorm.DB, email string) *model.User {
+	t.Helper()
+	user := &model.User{Name: "Test User", Email: email, Password: "not-a-hash"}
+	if err := repository.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// parseTestToken parses an access token issued with newTestJWTConfig
+func parseTestToken(t *testing.T, cfg *config.JWTConfig, token string) *middleware.Claims {
+	t.Helper()
+	keys, err := middleware.Keys(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &middleware.Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods())); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	return claims
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/internal/middleware"
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// TokenService issues access tokens, manages the refresh tokens used to renew them
+// and keeps track of revoked tokens. It implements middleware.TokenValidator.
+type TokenService interface {
+	IssueTokenPair(ctx context.Context, user *model.User, amr []string, client *model.ClientInfo) (*model.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client *model.ClientInfo) (*model.TokenPair, error)
+	Revoke(ctx context.Context, claims *middleware.Claims) error
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAllForUser(ctx context.Context, userID uint) error
+	ValidateToken(ctx context.Context, claims *middleware.Claims) error
+	ListSessions(ctx context.Context, userID uint) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID uint, keepSessionID string) error
+	IssueImpersonationToken(ctx context.Context, actor, target *model.User, ttl time.Duration) (*model.ImpersonationToken, error)
+	IssueClientToken(ctx context.Context, client *model.OAuthClient, scopes []string, ttl time.Duration) (*model.ClientTokenResponse, error)
+	RevokeAllForClient(ctx context.Context, clientID string) error
+}
+
+// refreshTokenRecord is the data stored in Redis for each issued refresh token.
+// Only the SHA-256 hash of the token is used as key, the token itself is never stored.
+type refreshTokenRecord struct {
+	UserID     uint     `json:"user_id"`
+	FamilyID   string   `json:"family_id"`
+	Generation int64    `json:"gen"`
+	AMR        []string `json:"amr,omitempty"` // Carried over to the access tokens issued on refresh
+}
+
+type tokenService struct {
+	userRepo  repository.UserRepository
+	roleRepo  repository.RoleRepository
+	redis     *redis.Client
+	jwtConfig *config.JWTConfig
+}
+
+// NewTokenService creates a new token service
+func NewTokenService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, redis *redis.Client, jwtConfig *config.JWTConfig) TokenService {
+	return &tokenService{
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		redis:     redis,
+		jwtConfig: jwtConfig,
+	}
+}
+
+// IssueTokenPair issues an access token and starts a new refresh token family for the user.
+// The family is tracked as a session of the given client. amr lists the
+// authentication methods the user logged in with.
+func (s *tokenService) IssueTokenPair(ctx context.Context, user *model.User, amr []string, client *model.ClientInfo) (*model.TokenPair, error) {
+	familyID, err := generateRandomToken(16)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate token family", err)
+	}
+
+	if err := s.recordSession(ctx, user.ID, familyID, client); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store session", err)
+	}
+
+	return s.issue(ctx, user, familyID, amr)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Every refresh token can
+// be used once; presenting an already used token revokes its whole family, since
+// it means either the client or an attacker holds a stolen copy.
+func (s *tokenService) Refresh(ctx context.Context, refreshToken string, client *model.ClientInfo) (*model.TokenPair, error) {
+	tokenHash := hashToken(refreshToken)
+
+	cached, err := s.redis.Get(ctx, refreshTokenKey(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, apperrors.NewUnauthorizedError("invalid or expired refresh token")
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load refresh token", err)
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to decode refresh token", err)
+	}
+
+	// Mark the token as used; if it already was, this is a replay
+	firstUse, err := s.redis.SetNX(ctx, refreshTokenUsedKey(tokenHash), 1, s.refreshTTL()).Result()
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to rotate refresh token", err)
+	}
+	if !firstUse {
+		logger.Warn("Refresh token reuse detected, revoking token family",
+			zap.Uint("user_id", record.UserID),
+			zap.String("family_id", record.FamilyID),
+		)
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to revoke refresh tokens", err)
+		}
+		return nil, apperrors.NewUnauthorizedError("refresh token has already been used")
+	}
+
+	// Tokens issued before a "logout everywhere" are no longer valid
+	generation, err := s.currentGeneration(ctx, record.UserID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token generation", err)
+	}
+	if record.Generation < generation {
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to revoke refresh tokens", err)
+		}
+		return nil, apperrors.NewUnauthorizedError("refresh token has been revoked")
+	}
+
+	// Reload the user so the new access token reflects its current state
+	user, err := s.userRepo.GetByID(database.WithPrimary(ctx), record.UserID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedErrorWithCause("invalid or expired refresh token", err)
+	}
+	if user.Disabled {
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, apperrors.NewInternalErrorWithCause("failed to revoke refresh tokens", err)
+		}
+		return nil, apperrors.NewForbiddenError("account is disabled")
+	}
+
+	if err := s.recordSession(ctx, user.ID, record.FamilyID, client); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store session", err)
+	}
+
+	return s.issue(ctx, user, record.FamilyID, record.AMR)
+}
+
+// Revoke adds the token's jti to the denylist until the token would have expired anyway
+func (s *tokenService) Revoke(ctx context.Context, claims *middleware.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return apperrors.NewValidationError("token cannot be revoked")
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.redis.Set(ctx, revokedTokenKey(claims.ID), 1, ttl).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke token", err)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the family the given refresh token belongs to
+func (s *tokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	cached, err := s.redis.Get(ctx, refreshTokenKey(hashToken(refreshToken))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load refresh token", err)
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to decode refresh token", err)
+	}
+
+	if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke refresh tokens", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser invalidates every access and refresh token issued to the user so far
+// by bumping the user's token generation, and ends all of the user's sessions
+func (s *tokenService) RevokeAllForUser(ctx context.Context, userID uint) error {
+	if err := s.redis.Incr(ctx, tokenGenerationKey(userID)).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke tokens", err)
+	}
+
+	sessionIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to load sessions", err)
+	}
+	for _, id := range sessionIDs {
+		if err := s.revokeFamily(ctx, id); err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to revoke sessions", err)
+		}
+	}
+	if err := s.redis.Del(ctx, userSessionsKey(userID)).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke sessions", err)
+	}
+	return nil
+}
+
+// IssueImpersonationToken issues an access token that acts as target and names
+// actor in its act claim. It belongs to no session and comes without a refresh
+// token. Revoking the tokens of either user revokes it.
+func (s *tokenService) IssueImpersonationToken(ctx context.Context, actor, target *model.User, ttl time.Duration) (*model.ImpersonationToken, error) {
+	generation, err := s.currentGeneration(ctx, target.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token generation", err)
+	}
+	actorGeneration, err := s.currentGeneration(ctx, actor.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token generation", err)
+	}
+
+	roles, err := s.roleRepo.GetUserRoles(ctx, target.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load user roles", err)
+	}
+	roleNames, permissions := roleClaims(roles)
+
+	accessToken, err := middleware.GenerateTokenWithClaims(s.jwtConfig, &middleware.Claims{
+		UserID:      target.ID,
+		Email:       target.Email,
+		Generation:  generation,
+		Roles:       roleNames,
+		Permissions: permissions,
+		Actor: &middleware.Actor{
+			UserID:     actor.ID,
+			Email:      actor.Email,
+			Generation: actorGeneration,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate token", err)
+	}
+
+	return &model.ImpersonationToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		UserID:      target.ID,
+		ActorID:     actor.ID,
+	}, nil
+}
+
+// IssueClientToken issues an access token for an OAuth client with the granted
+// scopes. Client tokens carry no user and cannot be refreshed; the client
+// requests a new one with its credentials.
+func (s *tokenService) IssueClientToken(ctx context.Context, client *model.OAuthClient, scopes []string, ttl time.Duration) (*model.ClientTokenResponse, error) {
+	generation, err := s.redis.Get(ctx, clientTokenGenerationKey(client.ClientID)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token generation", err)
+	}
+
+	scope := strings.Join(scopes, " ")
+	accessToken, err := middleware.GenerateTokenWithClaims(s.jwtConfig, &middleware.Claims{
+		ClientID:   client.ClientID,
+		Scope:      scope,
+		Generation: generation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   client.ClientID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate token", err)
+	}
+
+	return &model.ClientTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// RevokeAllForClient invalidates every token issued to the client by bumping
+// the client's token generation. Like the user's generation it never expires,
+// a counter starting over would make revoked tokens valid again.
+func (s *tokenService) RevokeAllForClient(ctx context.Context, clientID string) error {
+	if err := s.redis.Incr(ctx, clientTokenGenerationKey(clientID)).Err(); err != nil {
+		return apperrors.NewInternalErrorWithCause("failed to revoke tokens", err)
+	}
+	return nil
+}
+
+// ValidateToken rejects tokens that were revoked individually, by a generation bump
+// or together with their session. Lookup failures are treated as errors so that
+// revocation cannot be bypassed.
+func (s *tokenService) ValidateToken(ctx context.Context, claims *middleware.Claims) error {
+	generationKey := tokenGenerationKey(claims.UserID)
+	if claims.IsClient() {
+		generationKey = clientTokenGenerationKey(claims.ClientID)
+	}
+
+	pipe := s.redis.Pipeline()
+	revoked := pipe.Exists(ctx, revokedTokenKey(claims.ID))
+	generation := pipe.Get(ctx, generationKey)
+	var actorGeneration *redis.StringCmd
+	if claims.Actor != nil {
+		actorGeneration = pipe.Get(ctx, tokenGenerationKey(claims.Actor.UserID))
+	}
+	var lastSeen *redis.StringCmd
+	if claims.SessionID != "" {
+		lastSeen = pipe.HGet(ctx, sessionKey(claims.SessionID), "last_seen_at")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return apperrors.NewInternalErrorWithCause("failed to check token revocation", err)
+	}
+
+	if revoked.Val() > 0 {
+		return apperrors.NewUnauthorizedError("token has been revoked")
+	}
+
+	current, err := generation.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return apperrors.NewInternalErrorWithCause("failed to check token revocation", err)
+	}
+	if claims.Generation < current {
+		return apperrors.NewUnauthorizedError("token has been revoked")
+	}
+
+	// An impersonation ends when the admin's own tokens are revoked, e.g. on losing the admin role
+	if actorGeneration != nil {
+		current, err := actorGeneration.Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return apperrors.NewInternalErrorWithCause("failed to check token revocation", err)
+		}
+		if claims.Actor.Generation < current {
+			return apperrors.NewUnauthorizedError("token has been revoked")
+		}
+	}
+
+	// Tokens issued before sessions were tracked carry no session ID
+	if lastSeen != nil {
+		seenAt, err := lastSeen.Int64()
+		if errors.Is(err, redis.Nil) {
+			return apperrors.NewUnauthorizedError("session has been revoked")
+		}
+		if err != nil {
+			return apperrors.NewInternalErrorWithCause("failed to check token revocation", err)
+		}
+		s.touchSession(ctx, claims.SessionID, seenAt)
+	}
+
+	return nil
+}
+
+// issue signs a new access token and stores a new refresh token in the given family
+func (s *tokenService) issue(ctx context.Context, user *model.User, familyID string, amr []string) (*model.TokenPair, error) {
+	generation, err := s.currentGeneration(ctx, user.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load token generation", err)
+	}
+
+	roles, err := s.roleRepo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load user roles", err)
+	}
+	roleNames, permissions := roleClaims(roles)
+
+	accessToken, err := middleware.GenerateTokenWithClaims(s.jwtConfig, &middleware.Claims{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Generation:  generation,
+		Roles:       roleNames,
+		Permissions: permissions,
+		AMR:         amr,
+		SessionID:   familyID,
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate token", err)
+	}
+
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to generate refresh token", err)
+	}
+
+	recordJSON, err := json.Marshal(&refreshTokenRecord{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		Generation: generation,
+		AMR:        amr,
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to encode refresh token", err)
+	}
+
+	tokenHash := hashToken(refreshToken)
+	ttl := s.refreshTTL()
+	familyKey := refreshFamilyKey(familyID)
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, refreshTokenKey(tokenHash), recordJSON, ttl)
+	pipe.SAdd(ctx, familyKey, tokenHash)
+	pipe.Expire(ctx, familyKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to store refresh token", err)
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(middleware.AccessTokenTTL(s.jwtConfig).Seconds()),
+	}, nil
+}
+
+// revokeFamily deletes every refresh token that was issued in the given family
+// and the session it belongs to
+func (s *tokenService) revokeFamily(ctx context.Context, familyID string) error {
+	familyKey := refreshFamilyKey(familyID)
+
+	hashes, err := s.redis.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(hashes)*2+1)
+	for _, h := range hashes {
+		keys = append(keys, refreshTokenKey(h), refreshTokenUsedKey(h))
+	}
+	keys = append(keys, familyKey, sessionKey(familyID))
+
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+// currentGeneration returns the user's token generation, 0 if it was never bumped
+func (s *tokenService) currentGeneration(ctx context.Context, userID uint) (int64, error) {
+	generation, err := s.redis.Get(ctx, tokenGenerationKey(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (s *tokenService) refreshTTL() time.Duration {
+	return middleware.RefreshTokenTTL(s.jwtConfig)
+}
+
+func refreshTokenKey(tokenHash string) string {
+	return fmt.Sprintf("refresh_token:%s", tokenHash)
+}
+
+func refreshTokenUsedKey(tokenHash string) string {
+	return fmt.Sprintf("refresh_token:used:%s", tokenHash)
+}
+
+func refreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh_family:%s", familyID)
+}
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("revoked_token:%s", jti)
+}
+
+func clientTokenGenerationKey(clientID string) string {
+	return fmt.Sprintf("client_token_generation:%s", clientID)
+}
+
+func tokenGenerationKey(userID uint) string {
+	return fmt.Sprintf("token_generation:%d", userID)
+}
+
+// generateRandomToken returns a URL-safe random token built from n random bytes
+func generateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex encoded SHA-256 hash of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
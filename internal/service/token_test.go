@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/model"
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func newTestTokenService(t *testing.T) (TokenService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	client, _ := newTestRedis(t)
+	return NewTokenService(repository.NewUserRepository(db), repository.NewRoleRepository(db), client, newTestJWTConfig()), db
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "rotate@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, user, []string{"pwd"}, nil)
+	if err != nil {
+		t.Fatalf("IssueTokenPair() error = %v", err)
+	}
+	refreshed, err := tokens.Refresh(ctx, pair.RefreshToken, nil)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if refreshed.RefreshToken == pair.RefreshToken {
+		t.Error("Refresh() should issue a new refresh token")
+	}
+
+	claims := parseTestToken(t, newTestJWTConfig(), refreshed.AccessToken)
+	if claims.UserID != user.ID || len(claims.AMR) != 1 || claims.AMR[0] != "pwd" {
+		t.Errorf("unexpected claims after refresh: %+v", claims)
+	}
+	if claims.SessionID != parseTestToken(t, newTestJWTConfig(), pair.AccessToken).SessionID {
+		t.Error("a refresh should stay in the session of the login")
+	}
+
+	if _, err := tokens.Refresh(ctx, refreshed.RefreshToken, nil); err != nil {
+		t.Errorf("the rotated refresh token should be valid: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "reuse@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := tokens.Refresh(ctx, pair.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Presenting the used token again means a copy was stolen
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Fatalf("reusing a refresh token should be rejected, got %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, refreshed.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("reuse should revoke the whole family, got %v", err)
+	}
+
+	// Other logins of the user are not affected
+	other, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Refresh(ctx, other.RefreshToken, nil); err != nil {
+		t.Errorf("another session should stay valid: %v", err)
+	}
+}
+
+func TestRefreshAfterRevokeAllForUser(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "revoke@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.RevokeAllForUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("refresh tokens issued before RevokeAllForUser should be rejected, got %v", err)
+	}
+	claims := parseTestToken(t, newTestJWTConfig(), pair.AccessToken)
+	if err := tokens.ValidateToken(ctx, claims); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("access tokens issued before RevokeAllForUser should be rejected, got %v", err)
+	}
+
+	fresh, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), fresh.AccessToken)); err != nil {
+		t.Errorf("tokens issued afterwards should be valid: %v", err)
+	}
+}
+
+func TestRefreshDisabledUser(t *testing.T) {
+	ctx := context.Background()
+	tokens, db := newTestTokenService(t)
+	user := createTestUser(t, db, "disabled@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("disabled", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsForbiddenError(err) {
+		t.Errorf("a disabled user should not refresh, got %v", err)
+	}
+}
+
+func TestRefreshUnknownToken(t *testing.T) {
+	tokens, _ := newTestTokenService(t)
+	if _, err := tokens.Refresh(context.Background(), "unknown", nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("an unknown refresh token should be rejected, got %v", err)
+	}
+}
+
+func TestRevokeAllForClient(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	client, server := newTestRedis(t)
+	tokens := NewTokenService(repository.NewUserRepository(db), repository.NewRoleRepository(db), client, newTestJWTConfig())
+	oauthClient := &model.OAuthClient{ClientID: "reporting"}
+
+	issued, err := tokens.IssueClientToken(ctx, oauthClient, []string{"users:read"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.RevokeAllForClient(ctx, oauthClient.ClientID); err != nil {
+		t.Fatalf("RevokeAllForClient() error = %v", err)
+	}
+
+	// The revocation outlives the token instead of expiring with it
+	server.FastForward(2 * time.Hour)
+	if ttl := server.TTL(clientTokenGenerationKey(oauthClient.ClientID)); ttl != 0 {
+		t.Errorf("the client token generation should not expire, TTL = %v", ttl)
+	}
+	claims := parseTestToken(t, newTestJWTConfig(), issued.AccessToken)
+	if err := tokens.ValidateToken(ctx, claims); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("tokens issued before the revocation should be rejected, got %v", err)
+	}
+
+	fresh, err := tokens.IssueClientToken(ctx, oauthClient, []string{"users:read"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), fresh.AccessToken)); err != nil {
+		t.Errorf("tokens issued afterwards should be valid: %v", err)
+	}
+}
@@ -2,51 +2,75 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"time"
+	"errors"
 
 	"github.com/IndigoCloud6/go-web-template/internal/model"
 	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
 	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
 	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // UserService handles business logic for users
 type UserService interface {
 	Create(ctx context.Context, req *model.CreateUserRequest) (*model.User, error)
 	GetByID(ctx context.Context, id uint) (*model.User, error)
-	List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
+	List(ctx context.Context, req *model.ListUsersRequest) ([]*model.User, *query.PageInfo, error)
 	Update(ctx context.Context, id uint, req *model.UpdateUserRequest) (*model.User, error)
 	Delete(ctx context.Context, id uint) error
+	ListDeleted(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
+	Restore(ctx context.Context, id uint) (*model.User, error)
 }
 
 type userService struct {
-	repo  repository.UserRepository
-	redis *redis.Client
+	repo                repository.UserRepository
+	cache               *entityCache[model.User, uint]
+	tokenService        TokenService
+	roleService         RoleService
+	verificationService EmailVerificationService
+	passwordPolicy      *password.Policy
+	passwordHasher      *password.Hasher
+	cursors             *query.CursorCodec
 }
 
 // NewUserService creates a new user service
-func NewUserService(repo repository.UserRepository, redis *redis.Client) UserService {
+func NewUserService(repo repository.UserRepository, redis *redis.Client, tokenService TokenService, roleService RoleService, verificationService EmailVerificationService, passwordPolicy *password.Policy, passwordHasher *password.Hasher, cursors *query.CursorCodec) UserService {
 	return &userService{
-		repo:  repo,
-		redis: redis,
+		repo:                repo,
+		cache:               newUserCache(redis),
+		tokenService:        tokenService,
+		roleService:         roleService,
+		verificationService: verificationService,
+		passwordPolicy:      passwordPolicy,
+		passwordHasher:      passwordHasher,
+		cursors:             cursors,
 	}
 }
 
+// newUserCache creates the cache of users, shared by the services that change users
+func newUserCache(redis *redis.Client) *entityCache[model.User, uint] {
+	return newEntityCache[model.User, uint](redis, "user", "users")
+}
+
 // Create creates a new user
 func (s *userService) Create(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
-	// Check if email already exists
-	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
+	// Check if email already exists, deleted users keep theirs until they are purged
+	existingUser, err := s.repo.GetByEmailWithDeleted(ctx, req.Email)
 	if err == nil && existingUser != nil {
 		return nil, apperrors.NewConflictError("email already exists")
 	}
 
+	if err := s.passwordPolicy.Validate("password", req.Password, req.Email); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(req.Password)
 	if err != nil {
 		return nil, apperrors.NewInternalErrorWithCause("failed to hash password", err)
 	}
@@ -54,7 +78,7 @@ func (s *userService) Create(ctx context.Context, req *model.CreateUserRequest)
 	user := &model.User{
 		Name:     req.Name,
 		Email:    req.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Age:      req.Age,
 	}
 
@@ -62,126 +86,152 @@ func (s *userService) Create(ctx context.Context, req *model.CreateUserRequest)
 		return nil, apperrors.NewInternalErrorWithCause("failed to create user", err)
 	}
 
-	// Clear user list cache - note: in production, use a more sophisticated cache invalidation strategy
-	// For simplicity in this template, we just mark a cache version or use specific keys
-	// This is a simplified approach; consider using cache tags or versioning in production
-	keys, _ := s.redis.Keys(ctx, "users:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
+	// The user exists at this point, a missing role only limits what they can do
+	if err := s.roleService.AssignDefaultRoles(ctx, user); err != nil {
+		logger.Warn("Failed to assign default roles", zap.Uint("user_id", user.ID), zap.Error(err))
+	}
+
+	// The link can be requested again through the resend endpoint
+	if err := s.verificationService.SendVerification(ctx, user, user.Email); err != nil {
+		logger.Warn("Failed to send verification email", zap.Uint("user_id", user.ID), zap.Error(err))
 	}
 
+	// Clear user list cache
+	s.cache.InvalidateLists(ctx)
+
 	return user, nil
 }
 
 // GetByID retrieves a user by ID with caching
 func (s *userService) GetByID(ctx context.Context, id uint) (*model.User, error) {
-	cacheKey := fmt.Sprintf("user:%d", id)
-
-	// Try to get from cache
-	cached, err := s.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var user model.User
-		if err := json.Unmarshal([]byte(cached), &user); err == nil {
-			return &user, nil
-		}
-	}
-
-	// Get from database
-	user, err := s.repo.GetByID(ctx, id)
+	user, err := s.cache.Get(ctx, id, s.repo.GetByID)
 	if err != nil {
 		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
 	}
-
-	// Cache the result
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		logger.Warn("Failed to marshal user for caching", zap.Error(err))
-	} else {
-		s.redis.Set(ctx, cacheKey, userJSON, 5*time.Minute)
-	}
-
 	return user, nil
 }
 
-// List retrieves a list of users with pagination
-func (s *userService) List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
-	if page < 1 {
-		page = 1
+// List retrieves a page of the users matching the request's filter. Pages
+// are numbered, or follow a cursor of a previous page. The page and page size
+// in req are normalized to the values used.
+func (s *userService) List(ctx context.Context, req *model.ListUsersRequest) ([]*model.User, *query.PageInfo, error) {
+	if req.Page < 1 {
+		req.Page = 1
 	}
-	if pageSize < 1 {
-		pageSize = 10
+	if req.PageSize < 1 {
+		req.PageSize = 10
 	}
-	if pageSize > 100 {
-		pageSize = 100
+	if req.PageSize > 100 {
+		req.PageSize = 100
 	}
 
-	offset := (page - 1) * pageSize
-
-	users, err := s.repo.List(ctx, offset, pageSize)
+	sorts, err := query.ParseSort(req.Sort, model.UserSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, err := s.cursors.NewPage(req.Cursor, sorts, req.Page, req.PageSize)
 	if err != nil {
-		return nil, 0, apperrors.NewInternalErrorWithCause("failed to list users", err)
+		return nil, nil, err
 	}
 
-	total, err := s.repo.Count(ctx)
+	users, err := s.repo.List(ctx, &req.UserFilter, page)
 	if err != nil {
-		return nil, 0, apperrors.NewInternalErrorWithCause("failed to count users", err)
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to list users", err)
+	}
+	users, info, err := query.BuildPage(s.cursors, page, users)
+	if err != nil {
+		return nil, nil, apperrors.NewInternalErrorWithCause("failed to create cursors", err)
 	}
 
-	return users, total, nil
+	// Counting is optional for cursor pages, it scans every matching row
+	if count := req.Count; (count == nil && req.Cursor == "") || (count != nil && *count) {
+		total, err := s.repo.Count(ctx, &req.UserFilter)
+		if err != nil {
+			return nil, nil, apperrors.NewInternalErrorWithCause("failed to count users", err)
+		}
+		info.Total = &total
+	}
+
+	return users, info, nil
 }
 
 // Update updates a user
 func (s *userService) Update(ctx context.Context, id uint, req *model.UpdateUserRequest) (*model.User, error) {
-	user, err := s.repo.GetByID(ctx, id)
+	user, err := s.repo.GetByID(database.WithPrimary(ctx), id)
 	if err != nil {
 		return nil, apperrors.NewNotFoundErrorWithCause("user not found", err)
 	}
 
+	// Changing the password or disabling the account must end all existing sessions
+	revokeTokens := false
+
 	// Update fields if provided
 	if req.Name != "" {
 		user.Name = req.Name
 	}
-	if req.Email != "" {
-		// Check if new email already exists
-		existingUser, err := s.repo.GetByEmail(ctx, req.Email)
+	// A new email only replaces the current one once it has been verified
+	verifyEmail := false
+	if req.Email != "" && req.Email != user.Email {
+		// Check if new email already exists, deleted users keep theirs until they are purged
+		existingUser, err := s.repo.GetByEmailWithDeleted(ctx, req.Email)
 		if err == nil && existingUser != nil && existingUser.ID != id {
 			return nil, apperrors.NewConflictError("email already exists")
 		}
-		user.Email = req.Email
+		user.PendingEmail = req.Email
+		verifyEmail = true
+	} else if req.Email == user.Email {
+		// Changing the email back cancels a pending change
+		user.PendingEmail = ""
 	}
 	if req.Password != "" {
+		if err := s.passwordPolicy.Validate("password", req.Password, user.Email); err != nil {
+			return nil, err
+		}
+
 		// Hash password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := s.passwordHasher.Hash(req.Password)
 		if err != nil {
 			return nil, apperrors.NewInternalErrorWithCause("failed to hash password", err)
 		}
-		user.Password = string(hashedPassword)
+		user.Password = hashedPassword
+		revokeTokens = true
 	}
 	if req.Age > 0 {
 		user.Age = req.Age
 	}
+	if req.Disabled != nil {
+		if *req.Disabled && !user.Disabled {
+			revokeTokens = true
+		}
+		user.Disabled = *req.Disabled
+	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
 		return nil, apperrors.NewInternalErrorWithCause("failed to update user", err)
 	}
 
-	// Clear cache
-	cacheKey := fmt.Sprintf("user:%d", id)
-	s.redis.Del(ctx, cacheKey)
+	if revokeTokens {
+		if err := s.tokenService.RevokeAllForUser(ctx, id); err != nil {
+			return nil, err
+		}
+	}
 
-	// Clear user list cache
-	keys, _ := s.redis.Keys(ctx, "users:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
+	if verifyEmail {
+		if err := s.verificationService.SendVerification(ctx, user, user.PendingEmail); err != nil {
+			return nil, err
+		}
 	}
 
+	// Clear cache
+	s.cache.Invalidate(ctx, id)
+
 	return user, nil
 }
 
 // Delete deletes a user
 func (s *userService) Delete(ctx context.Context, id uint) error {
 	// Check if user exists
-	_, err := s.repo.GetByID(ctx, id)
+	_, err := s.repo.GetByID(database.WithPrimary(ctx), id)
 	if err != nil {
 		return apperrors.NewNotFoundErrorWithCause("user not found", err)
 	}
@@ -190,15 +240,61 @@ func (s *userService) Delete(ctx context.Context, id uint) error {
 		return apperrors.NewInternalErrorWithCause("failed to delete user", err)
 	}
 
+	if err := s.tokenService.RevokeAllForUser(ctx, id); err != nil {
+		return err
+	}
+
 	// Clear cache
-	cacheKey := fmt.Sprintf("user:%d", id)
-	s.redis.Del(ctx, cacheKey)
+	s.cache.Invalidate(ctx, id)
 
-	// Clear user list cache
-	keys, _ := s.redis.Keys(ctx, "users:list:*").Result()
-	if len(keys) > 0 {
-		s.redis.Del(ctx, keys...)
+	return nil
+}
+
+// ListDeleted retrieves users in the trash with pagination
+func (s *userService) ListDeleted(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
 	}
 
-	return nil
+	offset := (page - 1) * pageSize
+
+	users, err := s.repo.ListDeleted(ctx, offset, pageSize)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to list deleted users", err)
+	}
+
+	total, err := s.repo.CountDeleted(ctx)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalErrorWithCause("failed to count deleted users", err)
+	}
+
+	return users, total, nil
+}
+
+// Restore takes a user out of the trash. Tokens revoked on deletion stay
+// revoked, so the user has to log in again.
+func (s *userService) Restore(ctx context.Context, id uint) (*model.User, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFoundError("deleted user not found")
+		}
+		return nil, apperrors.NewInternalErrorWithCause("failed to restore user", err)
+	}
+
+	user, err := s.repo.GetByID(database.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, apperrors.NewInternalErrorWithCause("failed to load user", err)
+	}
+
+	// Clear user list cache
+	s.cache.InvalidateLists(ctx)
+
+	logger.Info("User restored", zap.Uint("user_id", id))
+	return user, nil
 }
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/repository"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
+	"gorm.io/gorm"
+)
+
+func newTestUserService(t *testing.T) (UserService, TokenService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	client, _ := newTestRedis(t)
+	userRepo := repository.NewUserRepository(db)
+	tokens := NewTokenService(userRepo, repository.NewRoleRepository(db), client, newTestJWTConfig())
+	users := NewUserService(userRepo, client, tokens, nil, nil, nil, nil, query.NewCursorCodec("test-cursor-secret"))
+	return users, tokens, db
+}
+
+func TestUserDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	users, tokens, db := newTestUserService(t)
+	user := createTestUser(t, db, "trash@example.com")
+
+	pair, err := tokens.IssueTokenPair(ctx, user, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetByID(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := users.GetByID(ctx, user.ID); !apperrors.IsNotFoundError(err) {
+		t.Errorf("a deleted user should not be found, also not from the cache, got %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, nil); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("deleting a user should revoke their tokens, got %v", err)
+	}
+
+	// The email stays taken while the user is in the trash
+	if _, err := repository.NewUserRepository(db).GetByEmailWithDeleted(ctx, user.Email); err != nil {
+		t.Errorf("the deleted user should keep the email: %v", err)
+	}
+	trash, total, err := users.ListDeleted(ctx, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(trash) != 1 || trash[0].ID != user.ID {
+		t.Fatalf("the user should be in the trash, got %d of %d", len(trash), total)
+	}
+
+	if _, err := users.Restore(ctx, user.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := users.GetByID(ctx, user.ID); err != nil {
+		t.Errorf("a restored user should be found: %v", err)
+	}
+	if err := tokens.ValidateToken(ctx, parseTestToken(t, newTestJWTConfig(), pair.AccessToken)); !apperrors.IsUnauthorizedError(err) {
+		t.Errorf("tokens revoked on deletion should stay revoked, got %v", err)
+	}
+	if _, total, err := users.ListDeleted(ctx, 1, 10); err != nil || total != 0 {
+		t.Errorf("the trash should be empty after the restore, got %d (%v)", total, err)
+	}
+}
@@ -4,11 +4,17 @@
 package wire
 
 import (
+	"errors"
+
 	"github.com/IndigoCloud6/go-web-template/internal/config"
 	"github.com/IndigoCloud6/go-web-template/internal/handler"
 	"github.com/IndigoCloud6/go-web-template/internal/repository"
 	"github.com/IndigoCloud6/go-web-template/internal/service"
 	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/notifier"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	pkgredis "github.com/IndigoCloud6/go-web-template/pkg/redis"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -17,39 +23,117 @@ import (
 
 // Handlers holds all the application handlers
 type Handlers struct {
-	UserHandler    *handler.UserHandler
-	ProductHandler *handler.ProductHandler
-	AuthHandler    *handler.AuthHandler
+	UserHandler          *handler.UserHandler
+	ProductHandler       *handler.ProductHandler
+	AuthHandler          *handler.AuthHandler
+	RoleHandler          *handler.RoleHandler
+	MFAHandler           *handler.MFAHandler
+	LockoutHandler       *handler.LockoutHandler
+	APIKeyHandler        *handler.APIKeyHandler
+	ImpersonationHandler *handler.ImpersonationHandler
+	AuditHandler         *handler.AuditHandler
+	OAuthClientHandler   *handler.OAuthClientHandler
+}
+
+// App holds the handlers together with the database and services used directly by main
+type App struct {
+	DB            *gorm.DB
+	Replicas      *database.Replicas
+	Handlers      *Handlers
+	TokenService  service.TokenService
+	RoleService   service.RoleService
+	APIKeyService service.APIKeyService
+	PurgeService  service.PurgeService
 }
 
 // InitializeApp initializes the application with all dependencies
-func InitializeApp(cfg *config.Config) (*Handlers, error) {
+func InitializeApp(cfg *config.Config) (*App, error) {
 	wire.Build(
 		// Database
 		provideDatabase,
+		provideReplicas,
 		// Redis
 		provideRedis,
 		// JWT Config
 		provideJWTConfig,
+		// RBAC Config
+		provideRBACConfig,
+		// Auth Config
+		provideAuthConfig,
+		// Notifier
+		provideNotifier,
+		// Lockout Config
+		provideLockoutConfig,
+		// OIDC
+		provideOIDCConfig,
+		provideOIDCClient,
+		// Password policy and hashing
+		providePasswordConfig,
+		password.NewPolicy,
+		password.NewHasher,
+		// Trash Config
+		provideTrashConfig,
+		// List cursors
+		provideCursorCodec,
 		// Repository
 		repository.NewUserRepository,
 		repository.NewProductRepository,
+		repository.NewRoleRepository,
+		repository.NewMFARepository,
+		repository.NewAPIKeyRepository,
+		repository.NewIdentityRepository,
+		repository.NewAuditRepository,
+		repository.NewOAuthClientRepository,
+		repository.NewTxManager,
 		// Service
 		service.NewUserService,
 		service.NewProductService,
 		service.NewAuthService,
+		service.NewTokenService,
+		service.NewRoleService,
+		service.NewEmailVerificationService,
+		service.NewMFAService,
+		service.NewLoginLimiter,
+		service.NewAPIKeyService,
+		service.NewOIDCService,
+		service.NewAuditService,
+		service.NewImpersonationService,
+		service.NewOAuthClientService,
+		service.NewPurgeService,
 		// Handler
 		handler.NewUserHandler,
 		handler.NewProductHandler,
 		handler.NewAuthHandler,
+		handler.NewRoleHandler,
+		handler.NewMFAHandler,
+		handler.NewLockoutHandler,
+		handler.NewAPIKeyHandler,
+		handler.NewImpersonationHandler,
+		handler.NewAuditHandler,
+		handler.NewOAuthClientHandler,
 		// Handlers struct
 		wire.Struct(new(Handlers), "*"),
+		// App struct
+		wire.Struct(new(App), "*"),
 	)
 	return nil, nil
 }
 
-func provideDatabase(cfg *config.Config) (*gorm.DB, error) {
-	return database.NewMySQL(&cfg.Database)
+// provideDatabase connects to the primary database and registers the read
+// replicas on it, see database.Reader
+func provideDatabase(cfg *config.Config, replicas *database.Replicas) (*gorm.DB, error) {
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(replicas); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func provideReplicas(cfg *config.Config) *database.Replicas {
+	return database.NewReplicas(&cfg.Database)
 }
 
 func provideRedis(cfg *config.Config) (*redis.Client, error) {
@@ -59,3 +143,44 @@ func provideRedis(cfg *config.Config) (*redis.Client, error) {
 func provideJWTConfig(cfg *config.Config) *config.JWTConfig {
 	return &cfg.JWT
 }
+
+func provideRBACConfig(cfg *config.Config) *config.RBACConfig {
+	return &cfg.RBAC
+}
+
+func provideAuthConfig(cfg *config.Config) *config.AuthConfig {
+	return &cfg.Auth
+}
+
+func provideNotifier(cfg *config.Config) (notifier.Notifier, error) {
+	return notifier.New(&cfg.Notifier)
+}
+
+func provideLockoutConfig(cfg *config.Config) *config.LockoutConfig {
+	return &cfg.Lockout
+}
+
+func provideOIDCConfig(cfg *config.Config) *config.OIDCConfig {
+	return &cfg.OIDC
+}
+
+func provideOIDCClient(cfg *config.Config) *oidc.Client {
+	return oidc.New(&cfg.OIDC)
+}
+
+func providePasswordConfig(cfg *config.Config) *config.PasswordConfig {
+	return &cfg.Password
+}
+
+func provideTrashConfig(cfg *config.Config) *config.TrashConfig {
+	return &cfg.Trash
+}
+
+// provideCursorCodec requires a secret of its own, so cursors are never signed
+// with a key that also signs tokens
+func provideCursorCodec(cfg *config.Config) (*query.CursorCodec, error) {
+	if cfg.Pagination.CursorSecret == "" {
+		return nil, errors.New("pagination.cursor_secret is required")
+	}
+	return query.NewCursorCodec(cfg.Pagination.CursorSecret), nil
+}
@@ -7,11 +7,17 @@
 package wire
 
 import (
+	"errors"
+
 	"github.com/IndigoCloud6/go-web-template/internal/config"
 	"github.com/IndigoCloud6/go-web-template/internal/handler"
 	"github.com/IndigoCloud6/go-web-template/internal/repository"
 	"github.com/IndigoCloud6/go-web-template/internal/service"
 	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"github.com/IndigoCloud6/go-web-template/pkg/notifier"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc"
+	"github.com/IndigoCloud6/go-web-template/pkg/password"
+	"github.com/IndigoCloud6/go-web-template/pkg/query"
 	redis2 "github.com/IndigoCloud6/go-web-template/pkg/redis"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -20,8 +26,9 @@ import (
 // Injectors from wire.go:
 
 // InitializeApp initializes the application with all dependencies
-func InitializeApp(cfg *config.Config) (*Handlers, error) {
-	db, err := provideDatabase(cfg)
+func InitializeApp(cfg *config.Config) (*App, error) {
+	replicas := provideReplicas(cfg)
+	db, err := provideDatabase(cfg, replicas)
 	if err != nil {
 		return nil, err
 	}
@@ -30,33 +37,128 @@ func InitializeApp(cfg *config.Config) (*Handlers, error) {
 	if err != nil {
 		return nil, err
 	}
-	userService := service.NewUserService(userRepository, client)
+	roleRepository := repository.NewRoleRepository(db)
+	jwtConfig := provideJWTConfig(cfg)
+	tokenService := service.NewTokenService(userRepository, roleRepository, client, jwtConfig)
+	rbacConfig := provideRBACConfig(cfg)
+	txManager := repository.NewTxManager(db)
+	roleService := service.NewRoleService(roleRepository, userRepository, tokenService, txManager, rbacConfig)
+	notifierNotifier, err := provideNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	authConfig := provideAuthConfig(cfg)
+	emailVerificationService := service.NewEmailVerificationService(userRepository, roleService, client, notifierNotifier, authConfig)
+	passwordConfig := providePasswordConfig(cfg)
+	policy, err := password.NewPolicy(passwordConfig)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := password.NewHasher(passwordConfig)
+	if err != nil {
+		return nil, err
+	}
+	cursorCodec, err := provideCursorCodec(cfg)
+	if err != nil {
+		return nil, err
+	}
+	userService := service.NewUserService(userRepository, client, tokenService, roleService, emailVerificationService, policy, hasher, cursorCodec)
 	userHandler := handler.NewUserHandler(userService)
 	productRepository := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepository, client)
+	productService := service.NewProductService(productRepository, client, cursorCodec)
 	productHandler := handler.NewProductHandler(productService)
-	authService := service.NewAuthService(userRepository)
-	jwtConfig := provideJWTConfig(cfg)
-	authHandler := handler.NewAuthHandler(authService, jwtConfig)
+	lockoutConfig := provideLockoutConfig(cfg)
+	loginLimiter := service.NewLoginLimiter(client, lockoutConfig)
+	authService := service.NewAuthService(userRepository, userService, loginLimiter, client, notifierNotifier, tokenService, policy, hasher, authConfig)
+	mfaRepository := repository.NewMFARepository(db)
+	mfaService := service.NewMFAService(mfaRepository, userRepository, loginLimiter, client, authConfig)
+	oidcClient := provideOIDCClient(cfg)
+	identityRepository := repository.NewIdentityRepository(db)
+	oidcConfig := provideOIDCConfig(cfg)
+	oidcService := service.NewOIDCService(oidcClient, identityRepository, userRepository, roleService, tokenService, txManager, client, hasher, oidcConfig)
+	authHandler := handler.NewAuthHandler(authService, tokenService, emailVerificationService, mfaService, oidcService, jwtConfig)
+	roleHandler := handler.NewRoleHandler(roleService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	lockoutHandler := handler.NewLockoutHandler(loginLimiter)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, userRepository, roleRepository)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	auditRepository := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepository)
+	impersonationService := service.NewImpersonationService(userRepository, roleRepository, tokenService, auditService, authConfig)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	oauthClientRepository := repository.NewOAuthClientRepository(db)
+	oauthClientService := service.NewOAuthClientService(oauthClientRepository, tokenService, authConfig)
+	oauthClientHandler := handler.NewOAuthClientHandler(oauthClientService)
 	handlers := &Handlers{
-		UserHandler:    userHandler,
-		ProductHandler: productHandler,
-		AuthHandler:    authHandler,
+		UserHandler:          userHandler,
+		ProductHandler:       productHandler,
+		AuthHandler:          authHandler,
+		RoleHandler:          roleHandler,
+		MFAHandler:           mfaHandler,
+		LockoutHandler:       lockoutHandler,
+		APIKeyHandler:        apiKeyHandler,
+		ImpersonationHandler: impersonationHandler,
+		AuditHandler:         auditHandler,
+		OAuthClientHandler:   oauthClientHandler,
+	}
+	trashConfig := provideTrashConfig(cfg)
+	purgeService := service.NewPurgeService(userRepository, productRepository, trashConfig)
+	app := &App{
+		DB:            db,
+		Replicas:      replicas,
+		Handlers:      handlers,
+		TokenService:  tokenService,
+		RoleService:   roleService,
+		APIKeyService: apiKeyService,
+		PurgeService:  purgeService,
 	}
-	return handlers, nil
+	return app, nil
 }
 
 // wire.go:
 
 // Handlers holds all the application handlers
 type Handlers struct {
-	UserHandler    *handler.UserHandler
-	ProductHandler *handler.ProductHandler
-	AuthHandler    *handler.AuthHandler
+	UserHandler          *handler.UserHandler
+	ProductHandler       *handler.ProductHandler
+	AuthHandler          *handler.AuthHandler
+	RoleHandler          *handler.RoleHandler
+	MFAHandler           *handler.MFAHandler
+	LockoutHandler       *handler.LockoutHandler
+	APIKeyHandler        *handler.APIKeyHandler
+	ImpersonationHandler *handler.ImpersonationHandler
+	AuditHandler         *handler.AuditHandler
+	OAuthClientHandler   *handler.OAuthClientHandler
+}
+
+// App holds the handlers together with the database and services used directly by main
+type App struct {
+	DB            *gorm.DB
+	Replicas      *database.Replicas
+	Handlers      *Handlers
+	TokenService  service.TokenService
+	RoleService   service.RoleService
+	APIKeyService service.APIKeyService
+	PurgeService  service.PurgeService
+}
+
+// provideDatabase connects to the primary database and registers the read
+// replicas on it, see database.Reader
+func provideDatabase(cfg *config.Config, replicas *database.Replicas) (*gorm.DB, error) {
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(replicas); err != nil {
+		return nil, err
+	}
+	return db, nil
 }
 
-func provideDatabase(cfg *config.Config) (*gorm.DB, error) {
-	return database.NewMySQL(&cfg.Database)
+func provideReplicas(cfg *config.Config) *database.Replicas {
+	return database.NewReplicas(&cfg.Database)
 }
 
 func provideRedis(cfg *config.Config) (*redis.Client, error) {
@@ -66,3 +168,44 @@ func provideRedis(cfg *config.Config) (*redis.Client, error) {
 func provideJWTConfig(cfg *config.Config) *config.JWTConfig {
 	return &cfg.JWT
 }
+
+func provideRBACConfig(cfg *config.Config) *config.RBACConfig {
+	return &cfg.RBAC
+}
+
+func provideAuthConfig(cfg *config.Config) *config.AuthConfig {
+	return &cfg.Auth
+}
+
+func provideNotifier(cfg *config.Config) (notifier.Notifier, error) {
+	return notifier.New(&cfg.Notifier)
+}
+
+func provideLockoutConfig(cfg *config.Config) *config.LockoutConfig {
+	return &cfg.Lockout
+}
+
+func provideOIDCConfig(cfg *config.Config) *config.OIDCConfig {
+	return &cfg.OIDC
+}
+
+func provideOIDCClient(cfg *config.Config) *oidc.Client {
+	return oidc.New(&cfg.OIDC)
+}
+
+func providePasswordConfig(cfg *config.Config) *config.PasswordConfig {
+	return &cfg.Password
+}
+
+func provideTrashConfig(cfg *config.Config) *config.TrashConfig {
+	return &cfg.Trash
+}
+
+// provideCursorCodec requires a secret of its own, so cursors are never signed
+// with a key that also signs tokens
+func provideCursorCodec(cfg *config.Config) (*query.CursorCodec, error) {
+	if cfg.Pagination.CursorSecret == "" {
+		return nil, errors.New("pagination.cursor_secret is required")
+	}
+	return query.NewCursorCodec(cfg.Pagination.CursorSecret), nil
+}
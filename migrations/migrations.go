@@ -0,0 +1,22 @@
+// Package migrations embeds the versioned SQL migrations of the database
+// schema, one directory per database driver
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS holds the migration files, named as described in pkg/migrate
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
+
+// For returns the migrations of a database driver: mysql, postgres or sqlite
+func For(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(FS, driver); err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+	return fs.Sub(FS, driver)
+}
@@ -0,0 +1,138 @@
+-- Tables as created by GORM AutoMigrate before versioned migrations were
+-- introduced. IF NOT EXISTS lets existing databases adopt the migrations.
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `email` varchar(100) NOT NULL,
+  `password` varchar(255) NOT NULL,
+  `age` int,
+  `disabled` boolean NOT NULL DEFAULT false,
+  `email_verified_at` datetime(3) NULL,
+  `pending_email` varchar(100),
+  `mfa_enabled` boolean NOT NULL DEFAULT false,
+  `mfa_secret` varchar(64),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_users_email` (`email`),
+  INDEX `idx_users_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `products` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(200) NOT NULL,
+  `description` text,
+  `price` decimal(10,2) NOT NULL,
+  `stock` int NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_products_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `roles` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(50) NOT NULL,
+  `description` varchar(255),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_roles_name` (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `permissions` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `description` varchar(255),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_permissions_name` (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_roles` (
+  `user_id` bigint unsigned,
+  `role_id` bigint unsigned,
+  PRIMARY KEY (`user_id`, `role_id`),
+  CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+  `role_id` bigint unsigned,
+  `permission_id` bigint unsigned,
+  PRIMARY KEY (`role_id`, `permission_id`),
+  CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`),
+  CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `mfa_recovery_codes` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_mfa_recovery_codes_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `prefix` varchar(32) NOT NULL,
+  `key_hash` char(64) NOT NULL,
+  `scopes` text,
+  `expires_at` datetime(3) NULL,
+  `last_used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_api_keys_user_id` (`user_id`),
+  UNIQUE INDEX `idx_api_keys_prefix` (`prefix`)
+);
+
+CREATE TABLE IF NOT EXISTS `identities` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `issuer` varchar(255) NOT NULL,
+  `subject` varchar(255) NOT NULL,
+  `email` varchar(100),
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_identities_user_id` (`user_id`),
+  UNIQUE INDEX `idx_identities_issuer_subject` (`issuer`, `subject`)
+);
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `action` varchar(64) NOT NULL,
+  `actor_id` bigint unsigned NOT NULL,
+  `target_user_id` bigint unsigned,
+  `reason` varchar(500),
+  `ip` varchar(64),
+  `user_agent` varchar(512),
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_action` (`action`),
+  INDEX `idx_audit_logs_actor_id` (`actor_id`),
+  INDEX `idx_audit_logs_target_user_id` (`target_user_id`),
+  INDEX `idx_audit_logs_created_at` (`created_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `oauth_clients` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `client_id` varchar(64) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `secret_hash` char(64) NOT NULL,
+  `scopes` text,
+  `last_used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_oauth_clients_client_id` (`client_id`)
+);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "oauth_clients";
+DROP TABLE IF EXISTS "audit_logs";
+DROP TABLE IF EXISTS "identities";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "mfa_recovery_codes";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "user_roles";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "products";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,138 @@
+-- Tables as created by GORM AutoMigrate before versioned migrations were
+-- introduced. IF NOT EXISTS lets existing databases adopt the migrations.
+
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "email" varchar(100) NOT NULL,
+  "password" varchar(255) NOT NULL,
+  "age" integer,
+  "disabled" boolean NOT NULL DEFAULT false,
+  "email_verified_at" timestamptz,
+  "pending_email" varchar(100),
+  "mfa_enabled" boolean NOT NULL DEFAULT false,
+  "mfa_secret" varchar(64),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "products" (
+  "id" bigserial,
+  "name" varchar(200) NOT NULL,
+  "description" text,
+  "price" decimal(10,2) NOT NULL,
+  "stock" integer NOT NULL DEFAULT 0,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "roles" (
+  "id" bigserial,
+  "name" varchar(50) NOT NULL,
+  "description" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+  "id" bigserial,
+  "name" varchar(100) NOT NULL,
+  "description" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+  "user_id" bigint,
+  "role_id" bigint,
+  PRIMARY KEY ("user_id", "role_id"),
+  CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id"),
+  CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+  "role_id" bigint,
+  "permission_id" bigint,
+  PRIMARY KEY ("role_id", "permission_id"),
+  CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id"),
+  CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "mfa_recovery_codes" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "code_hash" char(64) NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mfa_recovery_codes_user_id" ON "mfa_recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "prefix" varchar(32) NOT NULL,
+  "key_hash" char(64) NOT NULL,
+  "scopes" text,
+  "expires_at" timestamptz,
+  "last_used_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_prefix" ON "api_keys" ("prefix");
+
+CREATE TABLE IF NOT EXISTS "identities" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "issuer" varchar(255) NOT NULL,
+  "subject" varchar(255) NOT NULL,
+  "email" varchar(100),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_identities_user_id" ON "identities" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_identities_issuer_subject" ON "identities" ("issuer", "subject");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+  "id" bigserial,
+  "action" varchar(64) NOT NULL,
+  "actor_id" bigint NOT NULL,
+  "target_user_id" bigint,
+  "reason" varchar(500),
+  "ip" varchar(64),
+  "user_agent" varchar(512),
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_action" ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_target_user_id" ON "audit_logs" ("target_user_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "oauth_clients" (
+  "id" bigserial,
+  "client_id" varchar(64) NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "secret_hash" char(64) NOT NULL,
+  "scopes" text,
+  "last_used_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_oauth_clients_client_id" ON "oauth_clients" ("client_id");
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+)
+
+func TestNewRejectsUnknownDriver(t *testing.T) {
+	if _, err := New(&config.DatabaseConfig{Driver: "oracle"}); err == nil {
+		t.Error("New() should reject an unknown driver")
+	}
+}
+
+func TestNewSQLiteRequiresPath(t *testing.T) {
+	if _, err := New(&config.DatabaseConfig{Driver: DriverSQLite}); err == nil {
+		t.Error("New() should require a path for sqlite")
+	}
+}
+
+func TestNewSQLiteMemory(t *testing.T) {
+	// Pool settings meant for a server must not split the in-memory database
+	db, err := New(&config.DatabaseConfig{Driver: DriverSQLite, Path: SQLiteMemory, MaxOpenConns: 10, ConnMaxLifetime: 1})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE notes (id integer)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if !db.Migrator().HasTable("notes") {
+		t.Error("the table should be visible to later queries")
+	}
+}
+
+func TestNewSQLiteFile(t *testing.T) {
+	path := t.TempDir() + "/app.db"
+	db, err := New(&config.DatabaseConfig{Driver: DriverSQLite, Path: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := db.Exec("CREATE TABLE notes (id integer)").Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	db, err = New(&config.DatabaseConfig{Driver: DriverSQLite, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+	if !db.Migrator().HasTable("notes") {
+		t.Error("the table should be kept in the file")
+	}
+
+	var foreignKeys int
+	db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys)
+	if foreignKeys != 1 {
+		t.Error("foreign keys should be enforced")
+	}
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/glebarez/go-sqlite"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MySQL error numbers of transactions that failed on a lock and succeed when retried
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// PostgreSQL error codes of transactions that conflicted with another transaction
+const (
+	postgresErrSerializationFailure = "40001"
+	postgresErrDeadlockDetected     = "40P01"
+)
+
+// sqliteBusy is the primary result code of SQLITE_BUSY and its extended codes
+const sqliteBusy = 5
+
+// IsRetryable reports whether err aborted a transaction because of lock
+// contention with another transaction, so running it again can succeed
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresErrSerializationFailure || pgErr.Code == postgresErrDeadlockDetected
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xff == sqliteBusy
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadlock", deadlock, true},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, true},
+		{"duplicate entry", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, false},
+		{"wrapped deadlock", fmt.Errorf("update user: %w", deadlock), true},
+		{"app error cause", apperrors.NewInternalErrorWithCause("failed to update user", deadlock), true},
+		{"postgres deadlock", &pgconn.PgError{Code: "40P01", Message: "deadlock detected"}, true},
+		{"postgres serialization failure", &pgconn.PgError{Code: "40001", Message: "could not serialize access"}, true},
+		{"postgres unique violation", &pgconn.PgError{Code: "23505", Message: "duplicate key value"}, false},
+		{"other error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lagFunc measures how far a replica is behind its primary
+type lagFunc func(ctx context.Context, db *gorm.DB) (time.Duration, error)
+
+// postgresLagQuery returns the seconds since the last replayed transaction.
+// A standby that has replayed everything it received is not lagging, however
+// long ago the primary last wrote.
+const postgresLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// replicationLag returns the lag measurement of the driver. Servers that are
+// not replicas report no lag.
+func replicationLag(driver string) lagFunc {
+	switch driver {
+	case "", DriverMySQL:
+		return mysqlLag
+	case DriverPostgres:
+		return postgresLag
+	default:
+		// SQLite has no replication, replicas are copies of the file
+		return func(context.Context, *gorm.DB) (time.Duration, error) { return 0, nil }
+	}
+}
+
+// mysqlLag reads Seconds_Behind_Source from SHOW REPLICA STATUS (MySQL 8.0.22
+// and later). It is NULL while the replication threads are stopped.
+func mysqlLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	rows, err := db.WithContext(ctx).Raw("SHOW REPLICA STATUS").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	var seconds sql.NullInt64
+	for i, column := range columns {
+		if column == "Seconds_Behind_Source" && values[i].Valid {
+			if err := seconds.Scan(values[i].String); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if !seconds.Valid {
+		return 0, errors.New("replication is not running")
+	}
+	return time.Duration(seconds.Int64) * time.Second, nil
+}
+
+func postgresLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	var seconds float64
+	if err := db.WithContext(ctx).Raw(postgresLagQuery).Scan(&seconds).Error; err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Logger writes GORM's logs through pkg/logger, so LoggerConfig.Level decides
+// what is logged: every statement at debug level, statements slower than the
+// threshold as warnings and failed statements as errors. Entries carry the
+// request ID of the context. Without an initialized logger nothing is logged.
+type Logger struct {
+	level  gormlogger.LogLevel
+	slow   time.Duration
+	params bool
+}
+
+// NewLogger creates the GORM logger for a database configuration
+func NewLogger(cfg *config.DatabaseConfig) *Logger {
+	slow := time.Duration(cfg.SlowQuery) * time.Millisecond
+	if slow <= 0 {
+		slow = 200 * time.Millisecond // default to 200 milliseconds
+	}
+	return &Logger{level: gormlogger.Info, slow: slow, params: cfg.LogParams}
+}
+
+// LogMode implements gormlogger.Interface, e.g. for sessions that silence the logs
+func (l *Logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	mode := *l
+	mode.level = level
+	return &mode
+}
+
+// Info implements gormlogger.Interface
+func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log(ctx, zapcore.InfoLevel, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log(ctx, zapcore.WarnLevel, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log(ctx, zapcore.ErrorLevel, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace implements gormlogger.Interface. Missing records are not errors, the
+// services turn them into not found responses.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		if l.enabled(zapcore.ErrorLevel) {
+			l.log(ctx, zapcore.ErrorLevel, "SQL query failed", append(l.statement(fc, elapsed), zap.Error(err))...)
+		}
+	case elapsed > l.slow && l.level >= gormlogger.Warn:
+		if l.enabled(zapcore.WarnLevel) {
+			l.log(ctx, zapcore.WarnLevel, "Slow SQL query", append(l.statement(fc, elapsed), zap.Duration("threshold", l.slow))...)
+		}
+	case l.level >= gormlogger.Info:
+		if l.enabled(zapcore.DebugLevel) {
+			l.log(ctx, zapcore.DebugLevel, "SQL query", l.statement(fc, elapsed)...)
+		}
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter. Statements are logged with their
+// placeholders unless DatabaseConfig.LogParams is set.
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.params {
+		return sql, params
+	}
+	return sql, nil
+}
+
+// statement returns the fields describing a statement. Building the SQL is
+// left until the entry is known to be logged.
+func (l *Logger) statement(fc func() (string, int64), elapsed time.Duration) []zap.Field {
+	sql, rows := fc()
+	fields := []zap.Field{
+		zap.String("sql", sql),
+		zap.Duration("duration", elapsed),
+		zap.String("source", source()),
+	}
+	// -1 when the statement does not report affected rows
+	if rows >= 0 {
+		fields = append(fields, zap.Int64("rows", rows))
+	}
+	return fields
+}
+
+// source returns the file and line of the code that ran the statement, the
+// first caller outside GORM and this logger
+func source() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.Contains(frame.Function, "pkg/database.(*Logger)") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func (l *Logger) enabled(level zapcore.Level) bool {
+	return logger.Logger != nil && logger.Logger.Core().Enabled(level)
+}
+
+// log writes an entry without caller, which would always point into GORM;
+// statements carry their source instead
+func (l *Logger) log(ctx context.Context, level zapcore.Level, msg string, fields ...zap.Field) {
+	if !l.enabled(level) {
+		return
+	}
+	if id := logger.RequestID(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	logger.Logger.WithOptions(zap.WithCaller(false)).Log(level, msg, fields...)
+}
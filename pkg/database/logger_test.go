@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// observeLogs points pkg/logger at an observer recording entries from level up
+func observeLogs(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	previous := logger.Logger
+	logger.Logger = zap.New(core)
+	t.Cleanup(func() { logger.Logger = previous })
+	return logs
+}
+
+func TestLoggerStatements(t *testing.T) {
+	logs := observeLogs(t, zapcore.DebugLevel)
+	db, err := New(&config.DatabaseConfig{Driver: DriverSQLite, Path: SQLiteMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+	db.Exec("CREATE TABLE notes (body text)")
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	db.WithContext(ctx).Exec("INSERT INTO notes (body) VALUES (?)", "secret")
+
+	entries := logs.FilterMessage("SQL query").FilterField(zap.String("request_id", "req-1")).All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one statement logged with the request ID, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if entries[0].Level != zapcore.DebugLevel || fields["sql"] != "INSERT INTO notes (body) VALUES (?)" || fields["rows"] != int64(1) ||
+		!strings.Contains(fields["source"].(string), "logger_test.go") {
+		t.Errorf("unexpected entry %v %v", entries[0].Level, fields)
+	}
+
+	// Missing records are not errors
+	var note struct{ Body string }
+	if err := db.Table("notes").Where("body = ?", "missing").Take(&note).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected gorm.ErrRecordNotFound, got %v", err)
+	}
+	if n := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); n != 0 {
+		t.Errorf("expected no errors, got %d", n)
+	}
+
+	db.Exec("INSERT INTO missing_table VALUES (1)")
+	if n := logs.FilterMessage("SQL query failed").FilterLevelExact(zapcore.ErrorLevel).Len(); n != 1 {
+		t.Errorf("expected the failed statement as error, got %d", n)
+	}
+}
+
+func TestLoggerParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params bool
+		want   string
+	}{
+		{name: "placeholders by default", want: "INSERT INTO notes (body) VALUES (?)"},
+		{name: "log_params", params: true, want: `INSERT INTO notes (body) VALUES ("secret")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLogs(t, zapcore.DebugLevel)
+			db, err := New(&config.DatabaseConfig{Driver: DriverSQLite, Path: SQLiteMemory, LogParams: tt.params})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				sqlDB, _ := db.DB()
+				sqlDB.Close()
+			}()
+			db.Exec("CREATE TABLE notes (body text)")
+			db.Exec("INSERT INTO notes (body) VALUES (?)", "secret")
+
+			if !tt.params {
+				for _, entry := range logs.FilterMessage("SQL query").All() {
+					if sql := entry.ContextMap()["sql"].(string); strings.Contains(sql, "secret") {
+						t.Errorf("parameter was logged: %s", sql)
+					}
+				}
+			}
+			if logs.FilterMessage("SQL query").FilterField(zap.String("sql", tt.want)).Len() != 1 {
+				t.Errorf("expected %s, got %v", tt.want, logs.All())
+			}
+		})
+	}
+}
+
+func TestLoggerSlowQuery(t *testing.T) {
+	l := NewLogger(&config.DatabaseConfig{SlowQuery: 100})
+	statement := func() (string, int64) { return "SELECT 1", 1 }
+
+	t.Run("slow", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.InfoLevel)
+		l.Trace(context.Background(), time.Now().Add(-time.Second), statement, nil)
+		entries := logs.FilterMessage("Slow SQL query").FilterLevelExact(zapcore.WarnLevel).All()
+		if len(entries) != 1 {
+			t.Fatalf("expected a slow query warning, got %v", logs.All())
+		}
+		if d := entries[0].ContextMap()["duration"].(time.Duration); d < time.Second {
+			t.Errorf("unexpected duration %v", d)
+		}
+	})
+
+	t.Run("fast statements follow the log level", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.InfoLevel)
+		built := false
+		l.Trace(context.Background(), time.Now(), func() (string, int64) {
+			built = true
+			return statement()
+		}, nil)
+		if logs.Len() != 0 || built {
+			t.Error("statements should not be built or logged above debug level")
+		}
+	})
+
+	t.Run("silent", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.DebugLevel)
+		l.LogMode(gormlogger.Silent).Trace(context.Background(), time.Now().Add(-time.Second), statement, errors.New("failed"))
+		if logs.Len() != 0 {
+			t.Errorf("silent mode should not log, got %v", logs.All())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.DebugLevel)
+		l.Trace(context.Background(), time.Now(), statement, gorm.ErrRecordNotFound)
+		if logs.FilterLevelExact(zapcore.DebugLevel).Len() != 1 || logs.Len() != 1 {
+			t.Errorf("a missing record should be logged as a plain statement, got %v", logs.All())
+		}
+	})
+}
@@ -2,12 +2,10 @@ package database
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/IndigoCloud6/go-web-template/internal/config"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // NewMySQL creates a new MySQL database connection
@@ -20,22 +18,5 @@ func NewMySQL(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 		cfg.Database,
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get database instance: %w", err)
-	}
-
-	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
-
-	return db, nil
+	return open(mysql.Open(dsn), cfg)
 }
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// NewPostgres creates a new PostgreSQL database connection
+func NewPostgres(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     cfg.Database,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+
+	return open(postgres.Open(dsn.String()), cfg)
+}
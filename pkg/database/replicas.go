@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// replicasPlugin is the name Replicas is registered under on the primary
+const replicasPlugin = "replicas"
+
+// replicaCheckTimeout bounds a single health check of a replica
+const replicaCheckTimeout = 5 * time.Second
+
+type primaryKey struct{}
+
+// Replicas holds the read replicas of a database. It is registered on the
+// primary as a gorm plugin, so Reader finds the replicas of a handle:
+//
+//	db, _ := database.New(cfg)
+//	db.Use(database.NewReplicas(cfg))
+//	database.Reader(db).Find(&users)
+//
+// Replicas are picked in turn. A replica failing its health check, or lagging
+// further behind the primary than allowed, is left out until it passes a
+// check again, and reads go to the primary when no replica is healthy.
+type Replicas struct {
+	replicas []*replica
+	next     atomic.Uint64
+	interval time.Duration
+	maxLag   time.Duration
+	mu       sync.Mutex // Serializes Check
+}
+
+type replica struct {
+	cfg     config.DatabaseConfig
+	name    string // For logging
+	db      atomic.Pointer[gorm.DB]
+	lag     lagFunc
+	healthy atomic.Bool
+	checked bool
+}
+
+// NewReplicas connects to the replicas configured in cfg. A replica that
+// cannot be reached is not an error: it stays out of rotation until a health
+// check manages to connect.
+func NewReplicas(cfg *config.DatabaseConfig) *Replicas {
+	interval := time.Duration(cfg.ReplicaCheck) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second // default to 10 seconds
+	}
+	maxLag := time.Duration(cfg.ReplicaMaxLag) * time.Second
+	if maxLag <= 0 {
+		maxLag = 30 * time.Second // default to 30 seconds
+	}
+
+	r := &Replicas{interval: interval, maxLag: maxLag}
+	for _, replicaCfg := range cfg.Replicas {
+		rep := &replica{cfg: replicaConfig(cfg, replicaCfg)}
+		rep.lag = replicationLag(rep.cfg.Driver)
+		rep.name = rep.cfg.Path
+		if rep.cfg.Driver != DriverSQLite {
+			rep.name = net.JoinHostPort(rep.cfg.Host, strconv.Itoa(rep.cfg.Port))
+		}
+		r.replicas = append(r.replicas, rep)
+	}
+	r.Check(context.Background())
+	return r
+}
+
+// replicaConfig is the configuration of the primary with the fields set for the replica
+func replicaConfig(primary *config.DatabaseConfig, replica config.ReplicaConfig) config.DatabaseConfig {
+	cfg := *primary
+	cfg.Replicas = nil
+	if replica.Host != "" {
+		cfg.Host = replica.Host
+	}
+	if replica.Port != 0 {
+		cfg.Port = replica.Port
+	}
+	if replica.User != "" {
+		cfg.User = replica.User
+	}
+	if replica.Password != "" {
+		cfg.Password = replica.Password
+	}
+	if replica.Path != "" {
+		cfg.Path = replica.Path
+	}
+	return cfg
+}
+
+// Name implements gorm.Plugin
+func (r *Replicas) Name() string {
+	return replicasPlugin
+}
+
+// Initialize implements gorm.Plugin
+func (r *Replicas) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// Replica returns the next healthy replica, or nil when there is none
+func (r *Replicas) Replica() *gorm.DB {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep.db.Load()
+		}
+	}
+	return nil
+}
+
+// Check pings the replicas, connecting to those that could not be reached
+// before, measures their replication lag and updates which of them are in
+// rotation
+func (r *Replicas) Check(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rep := range r.replicas {
+		err := rep.check(ctx, r.maxLag)
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy && rep.checked {
+			continue
+		}
+		rep.checked = true
+		if healthy {
+			logger.Info("Database replica is in rotation", zap.String("replica", rep.name))
+		} else {
+			logger.Warn("Database replica is out of rotation", zap.String("replica", rep.name), zap.Error(err))
+		}
+	}
+}
+
+func (rep *replica) check(ctx context.Context, maxLag time.Duration) error {
+	db := rep.db.Load()
+	if db == nil {
+		var err error
+		if db, err = New(&rep.cfg); err != nil {
+			return err
+		}
+		rep.db.Store(db)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+
+	lag, err := rep.lag(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to check replication lag: %w", err)
+	}
+	if lag > maxLag {
+		return fmt.Errorf("replication lag of %s exceeds %s", lag.Round(time.Second), maxLag)
+	}
+	return nil
+}
+
+// Run checks the replicas on every interval until ctx is cancelled
+func (r *Replicas) Run(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx)
+		}
+	}
+}
+
+// Reader returns the handle for reads that may lag slightly behind the
+// primary: a healthy replica when db has any, and db itself otherwise
+func Reader(db *gorm.DB) *gorm.DB {
+	if r, ok := db.Config.Plugins[replicasPlugin].(*Replicas); ok {
+		if replica := r.Replica(); replica != nil {
+			return replica
+		}
+	}
+	return db
+}
+
+// WithPrimary marks ctx so that reads with it go to the primary, e.g. to
+// read back data the request has just changed
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was marked with WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
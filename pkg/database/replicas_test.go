@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newNamedSQLite creates a SQLite database at path whose only row names it
+func newNamedSQLite(t *testing.T, path, name string) *gorm.DB {
+	t.Helper()
+	db, err := New(&config.DatabaseConfig{Driver: DriverSQLite, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE names (name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO names (name) VALUES (?)", name).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func readName(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var name string
+	if err := db.Raw("SELECT name FROM names").Scan(&name).Error; err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReplicas(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	dir := t.TempDir()
+	cfg := &config.DatabaseConfig{
+		Driver: DriverSQLite,
+		Path:   dir + "/primary.db",
+		Replicas: []config.ReplicaConfig{
+			{Path: dir + "/replica1.db"},
+			{Path: dir + "/replica2.db"},
+			{Path: dir + "/missing/replica3.db"},
+		},
+	}
+	db := newNamedSQLite(t, cfg.Path, "primary")
+	newNamedSQLite(t, cfg.Replicas[0].Path, "replica1")
+	newNamedSQLite(t, cfg.Replicas[1].Path, "replica2")
+
+	replicas := NewReplicas(cfg)
+	if err := db.Use(replicas); err != nil {
+		t.Fatal(err)
+	}
+
+	// The unreachable replica is skipped, the others take turns
+	seen := make(map[string]int)
+	for range 4 {
+		seen[readName(t, Reader(db))]++
+	}
+	if seen["replica1"] != 2 || seen["replica2"] != 2 {
+		t.Errorf("reads should alternate between the healthy replicas, got %v", seen)
+	}
+
+	// A replica failing its health check leaves the rotation
+	sqlDB, _ := replicas.replicas[0].db.Load().DB()
+	sqlDB.Close()
+	replicas.Check(context.Background())
+	for range 3 {
+		if name := readName(t, Reader(db)); name != "replica2" {
+			t.Errorf("read from %s, want replica2", name)
+		}
+	}
+
+	// Without a healthy replica reads go to the primary
+	sqlDB, _ = replicas.replicas[1].db.Load().DB()
+	sqlDB.Close()
+	replicas.Check(context.Background())
+	if name := readName(t, Reader(db)); name != "primary" {
+		t.Errorf("read from %s, want primary", name)
+	}
+}
+
+func TestReplicasLag(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	dir := t.TempDir()
+	cfg := &config.DatabaseConfig{
+		Driver:        DriverSQLite,
+		Path:          dir + "/primary.db",
+		Replicas:      []config.ReplicaConfig{{Path: dir + "/replica.db"}},
+		ReplicaMaxLag: 10,
+	}
+	db := newNamedSQLite(t, cfg.Path, "primary")
+	newNamedSQLite(t, cfg.Replicas[0].Path, "replica")
+
+	replicas := NewReplicas(cfg)
+	if err := db.Use(replicas); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lag      time.Duration
+		err      error
+		expected string
+	}{
+		{"within the limit", 10 * time.Second, nil, "replica"},
+		{"lagging", 11 * time.Second, nil, "primary"},
+		{"caught up", 0, nil, "replica"},
+		{"replication stopped", 0, errors.New("replication is not running"), "primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas.replicas[0].lag = func(context.Context, *gorm.DB) (time.Duration, error) {
+				return tt.lag, tt.err
+			}
+			replicas.Check(context.Background())
+			if name := readName(t, Reader(db)); name != tt.expected {
+				t.Errorf("read from %s, want %s", name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReaderWithoutReplicas(t *testing.T) {
+	db := newNamedSQLite(t, t.TempDir()+"/primary.db", "primary")
+	if Reader(db) != db {
+		t.Error("Reader() should return the primary without replicas")
+	}
+}
+
+func TestWithPrimary(t *testing.T) {
+	if UsesPrimary(context.Background()) {
+		t.Error("a plain context should not use the primary")
+	}
+	if !UsesPrimary(WithPrimary(context.Background())) {
+		t.Error("WithPrimary should mark the context")
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteMemory is the database.path of an in-memory SQLite database
+const SQLiteMemory = ":memory:"
+
+// NewSQLite creates a new SQLite database connection to the file at
+// cfg.Path, or to an in-memory database that lives as long as the connection.
+// Foreign keys are enforced and writers wait for each other instead of failing.
+func NewSQLite(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("database.path is required for sqlite")
+	}
+
+	pragmas := "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if cfg.Path == SQLiteMemory {
+		// Every connection would open a database of its own, and the database
+		// is gone once its connection is closed
+		memoryCfg := *cfg
+		memoryCfg.MaxOpenConns = 1
+		memoryCfg.MaxIdleConns = 1
+		memoryCfg.ConnMaxLifetime = 0
+		return open(sqlite.Open("file::memory:"+pragmas), &memoryCfg)
+	}
+
+	// Transactions take the write lock up front, so they wait for each other
+	// instead of failing when they upgrade from reading to writing
+	return open(sqlite.Open("file:"+cfg.Path+pragmas+"&_pragma=journal_mode(WAL)&_txlock=immediate"), cfg)
+}
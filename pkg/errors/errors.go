@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // ErrorType represents the type of error
@@ -22,13 +23,23 @@ const (
 	ConflictErrorType
 	// InternalError represents internal server errors (500)
 	InternalErrorType
+	// TooManyRequestsError represents rate limiting errors (429)
+	TooManyRequestsErrorType
 )
 
+// FieldError describes why the value of a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // AppError is a custom error type that provides more context
 type AppError struct {
-	Type    ErrorType
-	Message string
-	Err     error
+	Type       ErrorType
+	Message    string
+	Err        error
+	RetryAfter time.Duration // How long the client should wait before retrying, sent as Retry-After
+	Fields     []FieldError  // Per-field details of a validation error
 }
 
 // Error implements the error interface
@@ -59,6 +70,8 @@ func (e *AppError) HTTPStatusCode() int {
 		return http.StatusConflict
 	case InternalErrorType:
 		return http.StatusInternalServerError
+	case TooManyRequestsErrorType:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
@@ -81,6 +94,15 @@ func NewValidationErrorWithCause(message string, err error) *AppError {
 	}
 }
 
+// NewFieldValidationError creates a new validation error listing the rejected fields
+func NewFieldValidationError(message string, fields ...FieldError) *AppError {
+	return &AppError{
+		Type:    ValidationErrorType,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
 // NewNotFoundError creates a new not found error
 func NewNotFoundError(message string) *AppError {
 	return &AppError{
@@ -166,6 +188,15 @@ func NewInternalErrorWithCause(message string, err error) *AppError {
 	}
 }
 
+// NewTooManyRequestsError creates a new rate limiting error that tells the client when to retry
+func NewTooManyRequestsError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Type:       TooManyRequestsErrorType,
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
+
 // IsValidationError checks if the error is a validation error
 func IsValidationError(err error) bool {
 	var appErr *AppError
@@ -220,6 +251,34 @@ func IsInternalError(err error) bool {
 	return false
 }
 
+// IsTooManyRequestsError checks if the error is a rate limiting error
+func IsTooManyRequestsError(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Type == TooManyRequestsErrorType
+	}
+	return false
+}
+
+// GetRetryAfter returns how long the client should wait before retrying,
+// or 0 if the error does not say
+func GetRetryAfter(err error) time.Duration {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.RetryAfter
+	}
+	return 0
+}
+
+// GetFieldErrors returns the per-field details of a validation error, if any
+func GetFieldErrors(err error) []FieldError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
+
 // GetHTTPStatusCode returns the HTTP status code for an error
 // If the error is not an AppError, it returns 500
 func GetHTTPStatusCode(err error) int {
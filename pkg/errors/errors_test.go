@@ -2,8 +2,10 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestNewValidationError(t *testing.T) {
@@ -69,6 +71,48 @@ func TestNewInternalError(t *testing.T) {
 	}
 }
 
+func TestNewTooManyRequestsError(t *testing.T) {
+	err := NewTooManyRequestsError("too many requests", 30*time.Second)
+	if err.Type != TooManyRequestsErrorType {
+		t.Errorf("expected TooManyRequestsErrorType, got %v", err.Type)
+	}
+	if err.HTTPStatusCode() != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, err.HTTPStatusCode())
+	}
+	if !IsTooManyRequestsError(err) {
+		t.Error("IsTooManyRequestsError should return true for too many requests error")
+	}
+
+	wrapped := fmt.Errorf("login: %w", err)
+	if got := GetRetryAfter(wrapped); got != 30*time.Second {
+		t.Errorf("GetRetryAfter = %v, want %v", got, 30*time.Second)
+	}
+	if got := GetRetryAfter(NewValidationError("test")); got != 0 {
+		t.Errorf("GetRetryAfter should return 0 for other errors, got %v", got)
+	}
+}
+
+func TestNewFieldValidationError(t *testing.T) {
+	err := NewFieldValidationError("invalid password",
+		FieldError{Field: "password", Message: "too short"},
+		FieldError{Field: "password", Message: "too common"},
+	)
+	if !IsValidationError(err) {
+		t.Error("IsValidationError should return true for field validation error")
+	}
+	if err.HTTPStatusCode() != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, err.HTTPStatusCode())
+	}
+
+	fields := GetFieldErrors(fmt.Errorf("create user: %w", err))
+	if len(fields) != 2 || fields[0].Field != "password" || fields[1].Message != "too common" {
+		t.Errorf("GetFieldErrors = %v", fields)
+	}
+	if fields := GetFieldErrors(NewValidationError("test")); fields != nil {
+		t.Errorf("GetFieldErrors should return nil for other errors, got %v", fields)
+	}
+}
+
 func TestErrorWithCause(t *testing.T) {
 	cause := errors.New("original error")
 	err := NewInternalErrorWithCause("wrapper error", cause)
@@ -132,6 +176,7 @@ func TestGetHTTPStatusCode(t *testing.T) {
 		{NewForbiddenError("test"), http.StatusForbidden},
 		{NewConflictError("test"), http.StatusConflict},
 		{NewInternalError("test"), http.StatusInternalServerError},
+		{NewTooManyRequestsError("test", time.Minute), http.StatusTooManyRequests},
 		{errors.New("generic error"), http.StatusInternalServerError},
 	}
 
@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lockName identifies the advisory lock held while migrating, so only one
+// process migrates and the others wait until it is done
+const lockName = "schema_migrations"
+
+// postgresLockKey is lockName as a PostgreSQL advisory lock key
+const postgresLockKey = 7239152315
+
+// dialect holds what differs between the supported databases
+type dialect struct {
+	createTable string
+	// lock blocks until conn holds the migration lock and returns the
+	// function releasing it. Nil when the database needs no lock.
+	lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+	// numbered is set when placeholders are $1, $2, ... instead of ?
+	numbered bool
+}
+
+var dialects = map[string]*dialect{
+	"mysql": {
+		createTable: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+			"`version` bigint unsigned NOT NULL, " +
+			"`name` varchar(255) NOT NULL, " +
+			"`checksum` char(64) NOT NULL, " +
+			"`applied_at` datetime(3) NOT NULL, " +
+			"PRIMARY KEY (`version`))",
+		lock: lockMySQL,
+	},
+	"postgres": {
+		createTable: `CREATE TABLE IF NOT EXISTS "schema_migrations" (` +
+			`"version" bigint NOT NULL, ` +
+			`"name" varchar(255) NOT NULL, ` +
+			`"checksum" char(64) NOT NULL, ` +
+			`"applied_at" timestamptz NOT NULL, ` +
+			`PRIMARY KEY ("version"))`,
+		lock:     lockPostgres,
+		numbered: true,
+	},
+	// SQLite databases are local files that a single process migrates
+	"sqlite": {
+		createTable: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+			"`version` integer NOT NULL, " +
+			"`name` varchar(255) NOT NULL, " +
+			"`checksum` char(64) NOT NULL, " +
+			"`applied_at` datetime NOT NULL, " +
+			"PRIMARY KEY (`version`))",
+	},
+}
+
+// bind rewrites the ? placeholders of query for the dialect
+func (d *dialect) bind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lockMySQL takes a named lock with GET_LOCK, which gives up after lockTimeout
+func lockMySQL(ctx context.Context, conn *sql.Conn) (func(), error) {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&acquired); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return nil, errors.New("timed out waiting for another process to finish migrating")
+	}
+	// Released with a fresh context so a canceled migration does not keep the lock
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+	}, nil
+}
+
+// lockPostgres takes a session level advisory lock, waiting at most lockTimeout
+func lockPostgres(ctx context.Context, conn *sql.Conn) (func(), error) {
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", postgresLockKey); err != nil {
+		if errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+			return nil, errors.New("timed out waiting for another process to finish migrating")
+		}
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresLockKey)
+	}, nil
+}
@@ -0,0 +1,263 @@
+// Package migrate applies versioned SQL migrations and records them in the
+// schema_migrations table
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"time"
+)
+
+// lockTimeout is how long to wait for another process to finish migrating
+const lockTimeout = 10 * time.Minute
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   uint64     `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // Nil when pending
+	Modified  bool       `json:"modified,omitempty"`   // The up file changed after it was applied
+	Missing   bool       `json:"missing,omitempty"`    // Applied, but there is no file for it
+}
+
+// Migrator migrates a database to the migrations of a file system
+type Migrator struct {
+	db         *sql.DB
+	dialect    *dialect
+	migrations []Migration
+}
+
+// applied is a row of schema_migrations
+type applied struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// New creates a Migrator for the migrations in the root of fsys, see Load.
+// driver is the database of db: mysql, postgres or sqlite.
+func New(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// Latest returns the highest known version, 0 without migrations
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the last steps applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, history map[uint64]applied) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			if _, ok := history[m.migrations[i].Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, conn, m.migrations[i]); err != nil {
+				return err
+			}
+			done = append(done, m.migrations[i])
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To applies or rolls back migrations until exactly the migrations up to
+// version are applied, and returns the migrations it ran. Version 0 rolls
+// back everything.
+func (m *Migrator) To(ctx context.Context, version uint64) ([]Migration, error) {
+	if version != 0 && !m.known(version) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, history map[uint64]applied) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := history[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+			if err := m.rollback(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		for _, migration := range m.migrations {
+			if _, ok := history[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists the known migrations and applied migrations without a file,
+// sorted by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	history, err := m.history(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := history[migration.Version]; ok {
+			status.AppliedAt = &row.appliedAt
+			status.Modified = row.checksum != migration.Checksum
+			delete(history, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range history {
+		appliedAt := row.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: row.name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// locked runs fn on a connection holding the migration lock, after checking
+// that the applied migrations match the files
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, history map[uint64]applied) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != nil {
+		unlock, err := m.dialect.lock(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	history, err := m.history(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for version, row := range history {
+		if !m.known(version) {
+			return fmt.Errorf("applied migration %d_%s has no file", version, row.name)
+		}
+	}
+	for _, migration := range m.migrations {
+		if row, ok := history[migration.Version]; ok && row.checksum != migration.Checksum {
+			return fmt.Errorf("applied migration %d_%s was modified, add a new migration instead", migration.Version, migration.Name)
+		}
+	}
+	return fn(conn, history)
+}
+
+// history reads the applied migrations
+func (m *Migrator) history(ctx context.Context, conn *sql.Conn) (map[uint64]applied, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[uint64]applied)
+	for rows.Next() {
+		var version uint64
+		var row applied
+		if err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		history[version] = row
+	}
+	return history, rows.Err()
+}
+
+// apply runs the up statements of a migration and records it. MySQL commits
+// DDL statements implicitly, so there only the data changes of a failed
+// migration are rolled back.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return m.run(ctx, conn, migration, migration.Up, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, m.dialect.bind("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+			migration.Version, migration.Name, migration.Checksum, time.Now())
+		return err
+	})
+}
+
+// rollback runs the down statements of a migration and removes its record
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s cannot be rolled back, it has no down file", migration.Version, migration.Name)
+	}
+	return m.run(ctx, conn, migration, migration.Down, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, m.dialect.bind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+		return err
+	})
+}
+
+// run executes the statements of script and record in one transaction
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if err := record(tx); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// known reports whether there is a file for version
+func (m *Migrator) known(version uint64) bool {
+	return slices.ContainsFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	})
+}
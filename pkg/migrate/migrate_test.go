@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/migrations"
+	"github.com/IndigoCloud6/go-web-template/pkg/database"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.NewSQLite(&config.DatabaseConfig{Path: database.SQLiteMemory})
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *gorm.DB, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(sqlDB, database.DriverSQLite, fsys)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"000001_orders.up.sql":     {Data: []byte("CREATE TABLE orders (\n  id integer\n);\nINSERT INTO orders VALUES (1);")},
+		"000001_orders.down.sql":   {Data: []byte("DROP TABLE orders;")},
+		"000002_invoices.up.sql":   {Data: []byte("CREATE TABLE invoices (id integer);")},
+		"000002_invoices.down.sql": {Data: []byte("DROP TABLE invoices;")},
+		"000003_refunds.up.sql":    {Data: []byte("CREATE TABLE refunds (id integer);")},
+		"000003_refunds.down.sql":  {Data: []byte("DROP TABLE refunds;")},
+	}
+}
+
+func versions(done []Migration) []uint64 {
+	var v []uint64
+	for _, m := range done {
+		v = append(v, m.Version)
+	}
+	return v
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m := newTestMigrator(t, db, testMigrations())
+
+	done, err := m.To(ctx, 2)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("To(2) = %v, %v", versions(done), err)
+	}
+	if !db.Migrator().HasTable("invoices") || db.Migrator().HasTable("refunds") {
+		t.Fatal("To(2) should create orders and invoices only")
+	}
+
+	done, err = m.Up(ctx)
+	if err != nil || len(done) != 1 || done[0].Version != 3 {
+		t.Fatalf("Up() = %v, %v", versions(done), err)
+	}
+	if done, err = m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("second Up() = %v, %v", versions(done), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil || s.Modified || s.Missing {
+			t.Errorf("unexpected status %+v", s)
+		}
+	}
+
+	done, err = m.Down(ctx, 2)
+	if err != nil || len(done) != 2 || done[0].Version != 3 || done[1].Version != 2 {
+		t.Fatalf("Down(2) = %v, %v", versions(done), err)
+	}
+	if db.Migrator().HasTable("invoices") || !db.Migrator().HasTable("orders") {
+		t.Fatal("Down(2) should only keep orders")
+	}
+
+	done, err = m.To(ctx, 0)
+	if err != nil || len(done) != 1 || db.Migrator().HasTable("orders") {
+		t.Fatalf("To(0) = %v, %v", versions(done), err)
+	}
+}
+
+func TestMigratorRejectsModifiedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := testMigrations()
+	if _, err := newTestMigrator(t, db, fsys).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["000002_invoices.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE invoices (id bigint);")}
+	m := newTestMigrator(t, db, fsys)
+	if _, err := m.Up(ctx); err == nil {
+		t.Error("Up() should fail when an applied migration was modified")
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[1].Modified {
+		t.Errorf("status of the modified migration = %+v", statuses[1])
+	}
+
+	delete(fsys, "000003_refunds.up.sql")
+	delete(fsys, "000003_refunds.down.sql")
+	fsys["000002_invoices.up.sql"] = testMigrations()["000002_invoices.up.sql"]
+	m = newTestMigrator(t, db, fsys)
+	if _, err := m.Up(ctx); err == nil {
+		t.Error("Up() should fail when an applied migration has no file")
+	}
+	if statuses, _ := m.Status(ctx); len(statuses) != 3 || !statuses[2].Missing {
+		t.Errorf("statuses = %+v, want the removed migration reported as missing", statuses)
+	}
+}
+
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := testMigrations()
+	fsys["000002_invoices.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE invoices (id integer);\nINSERT INTO missing VALUES (1);")}
+
+	done, err := newTestMigrator(t, db, fsys).Up(ctx)
+	if err == nil || len(done) != 1 {
+		t.Fatalf("Up() = %v, %v, want only the first migration applied", versions(done), err)
+	}
+	if db.Migrator().HasTable("invoices") {
+		t.Error("the failed migration should be rolled back")
+	}
+}
+
+func TestEmbeddedSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys, err := migrations.For(database.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	m, err := New(sqlDB, database.DriverSQLite, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	for _, table := range []string{"users", "products", "roles", "user_roles", "oauth_clients"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s was not created", table)
+		}
+	}
+	if _, err := m.To(ctx, 0); err != nil {
+		t.Fatalf("To(0) error = %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("rolling back everything should drop the tables")
+	}
+}
@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/IndigoCloud6/go-web-template/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_orders.up.sql":       {Data: []byte("CREATE TABLE orders (id bigint);")},
+		"000002_add_orders.down.sql":     {Data: []byte("DROP TABLE orders;")},
+		"000001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE users (id bigint);")},
+		"000001_initial_schema.down.sql": {Data: []byte("DROP TABLE users;")},
+		"000003_backfill.up.sql":         {Data: []byte("UPDATE users SET name = '';")},
+		"README.md":                      {Data: []byte("not a migration")},
+	}
+
+	loaded, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("Load() returned %d migrations, want 3", len(loaded))
+	}
+	for i, want := range []uint64{1, 2, 3} {
+		if loaded[i].Version != want {
+			t.Errorf("migration %d has version %d, want %d", i, loaded[i].Version, want)
+		}
+	}
+	if loaded[1].Name != "add_orders" || loaded[1].Down != "DROP TABLE orders;" {
+		t.Errorf("unexpected migration %+v", loaded[1])
+	}
+	if loaded[2].Down != "" {
+		t.Errorf("migration without down file has Down = %q", loaded[2].Down)
+	}
+	if len(loaded[0].Checksum) != 64 || loaded[0].Checksum == loaded[1].Checksum {
+		t.Errorf("unexpected checksums %q and %q", loaded[0].Checksum, loaded[1].Checksum)
+	}
+}
+
+func TestLoadChecksumCoversUpOnly(t *testing.T) {
+	up := []byte("CREATE TABLE users (id bigint);")
+	before, err := Load(fstest.MapFS{"000001_users.up.sql": {Data: up}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := Load(fstest.MapFS{
+		"000001_users.up.sql":   {Data: up},
+		"000001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before[0].Checksum != after[0].Checksum {
+		t.Error("adding a down file should not change the checksum")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"duplicate version", fstest.MapFS{
+			"000001_users.up.sql":    {Data: []byte("SELECT 1;")},
+			"000001_products.up.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"missing up file", fstest.MapFS{
+			"000001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		}},
+		{"empty up file", fstest.MapFS{
+			"000001_users.up.sql": {Data: []byte("\n")},
+		}},
+		{"version zero", fstest.MapFS{
+			"000000_users.up.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys); err == nil {
+				t.Error("Load() should fail")
+			}
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := `-- Add orders
+CREATE TABLE orders (
+  id bigint,
+  note varchar(10) DEFAULT 'a;b'
+);
+
+-- Backfill
+UPDATE users SET name = 'x';
+UPDATE users SET age = 1`
+
+	want := []string{
+		"CREATE TABLE orders (\n  id bigint,\n  note varchar(10) DEFAULT 'a;b'\n)",
+		"UPDATE users SET name = 'x'",
+		"UPDATE users SET age = 1",
+	}
+	if got := statements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements() = %q, want %q", got, want)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	var versions [][]uint64
+	for driver := range dialects {
+		fsys, err := migrations.For(driver)
+		if err != nil {
+			t.Fatalf("migrations.For(%q) error = %v", driver, err)
+		}
+		loaded, err := Load(fsys)
+		if err != nil {
+			t.Fatalf("Load(%q) error = %v", driver, err)
+		}
+		if len(loaded) == 0 || loaded[0].Version != 1 {
+			t.Fatalf("expected the initial schema migration for %s", driver)
+		}
+
+		var driverVersions []uint64
+		for _, m := range loaded {
+			driverVersions = append(driverVersions, m.Version)
+			if m.Down == "" {
+				t.Errorf("%s migration %d_%s has no down file", driver, m.Version, m.Name)
+			}
+			for _, stmt := range statements(m.Up) {
+				if strings.HasSuffix(stmt, ";") {
+					t.Errorf("statement of %s migration %d_%s was not split: %q", driver, m.Version, m.Name, stmt)
+				}
+			}
+		}
+		versions = append(versions, driverVersions)
+	}
+
+	// Every driver has to get the same schema changes
+	for _, v := range versions[1:] {
+		if !reflect.DeepEqual(v, versions[0]) {
+			t.Errorf("drivers have different migration versions: %v and %v", versions[0], v)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers messages to users, e.g. password reset links.
+// Implement it to plug in an email or SMS provider.
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the notifier selected by the config
+func New(cfg *config.NotifierConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		return NewFileNotifier(cfg.FilePath)
+	default:
+		return nil, fmt.Errorf("unsupported notifier driver %q", cfg.Driver)
+	}
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier creates a notifier that writes messages to the application log.
+// It is meant for development only, since messages may contain secrets.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+// Send logs the message
+func (n *logNotifier) Send(ctx context.Context, msg *Message) error {
+	logger.Info("Notification",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier creates a notifier that appends messages as JSON lines to a file
+func NewFileNotifier(path string) (Notifier, error) {
+	if path == "" {
+		return nil, fmt.Errorf("notifier file_path is required for the file driver")
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create notifier directory: %w", err)
+		}
+	}
+	return &fileNotifier{path: path}, nil
+}
+
+// Send appends the message to the file
+func (n *fileNotifier) Send(ctx context.Context, msg *Message) error {
+	line, err := json.Marshal(struct {
+		*Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+)
+
+func TestFileNotifier_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail", "outbox.log")
+
+	n, err := New(&config.NotifierConfig{Driver: "file", FilePath: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := n.Send(context.Background(), &Message{To: to, Subject: "Hello", Body: "World"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open outbox: %v", err)
+	}
+	defer f.Close()
+
+	var recipients []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("failed to decode line: %v", err)
+		}
+		recipients = append(recipients, msg.To)
+	}
+
+	if len(recipients) != 2 || recipients[0] != "a@example.com" || recipients[1] != "b@example.com" {
+		t.Errorf("Unexpected recipients: %v", recipients)
+	}
+}
+
+func TestNew_UnsupportedDriver(t *testing.T) {
+	if _, err := New(&config.NotifierConfig{Driver: "pigeon"}); err == nil {
+		t.Error("Expected an error for an unsupported driver")
+	}
+}
@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// requestTimeout bounds every request to the provider
+const requestTimeout = 10 * time.Second
+
+// ErrNonceMismatch is returned when the ID token was not issued for the login being completed
+var ErrNonceMismatch = errors.New("id token nonce does not match")
+
+// Identity is the user information taken from a verified ID token
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AMR           []string // Authentication methods used at the provider, if it reports them
+}
+
+// Client is an OpenID Connect relying party using the authorization code flow with PKCE.
+// The provider configuration is discovered on first use and cached afterwards.
+type Client struct {
+	cfg        *config.OIDCConfig
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	provider *gooidc.Provider
+}
+
+// New creates a client for the provider in the config
+func New(cfg *config.OIDCConfig) *Client {
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Issuer returns the issuer URL of the provider
+func (c *Client) Issuer() string {
+	return c.cfg.Issuer
+}
+
+// AuthCodeURL returns the provider URL the user is sent to. The state is returned
+// to the callback unchanged, the nonce ends up in the ID token and the verifier
+// is the PKCE secret that has to be passed to Exchange.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.oauth2Config(provider).AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	), nil
+}
+
+// Exchange redeems the authorization code and verifies the ID token returned with it
+func (c *Client) Exchange(ctx context.Context, code, nonce, verifier string) (*Identity, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = c.context(ctx)
+
+	token, err := c.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response does not contain an id token")
+	}
+
+	idToken, err := provider.Verifier(&gooidc.Config{ClientID: c.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email         string    `json:"email"`
+		EmailVerified claimBool `json:"email_verified"`
+		Name          string    `json:"name"`
+		AMR           []string  `json:"amr"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode id token claims: %w", err)
+	}
+
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		AMR:           claims.AMR,
+	}, nil
+}
+
+// discover loads the provider configuration. Failures are not cached, so a
+// provider that is temporarily unreachable is retried on the next login.
+func (c *Client) discover(ctx context.Context) (*gooidc.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	provider, err := gooidc.NewProvider(c.context(ctx), c.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+
+	c.provider = provider
+	return provider, nil
+}
+
+func (c *Client) oauth2Config(provider *gooidc.Provider) *oauth2.Config {
+	scopes := c.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{gooidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		RedirectURL:  c.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// context makes the oauth2 and oidc packages use the client's HTTP client
+func (c *Client) context(ctx context.Context) context.Context {
+	return gooidc.ClientContext(ctx, c.httpClient)
+}
+
+// claimBool accepts booleans encoded as strings, which some providers send for email_verified
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(v == "true")
+	}
+	return nil
+}
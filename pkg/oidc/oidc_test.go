@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	"github.com/IndigoCloud6/go-web-template/pkg/oidc/oidctest"
+)
+
+func newTestClient(t *testing.T, user oidctest.User) (*Client, *oidctest.Provider) {
+	t.Helper()
+
+	provider, err := oidctest.NewProvider("test-client", "test-secret", user)
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	t.Cleanup(provider.Close)
+
+	client := New(&config.OIDCConfig{
+		Issuer:       provider.Issuer(),
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	})
+	return client, provider
+}
+
+// authorize runs the browser part of the flow and returns the authorization code
+func authorize(t *testing.T, client *Client, provider *oidctest.Provider, state, nonce, verifier string) string {
+	t.Helper()
+
+	authURL, err := client.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	if parsed.Query().Get("code_challenge_method") != "S256" || parsed.Query().Get("nonce") != nonce {
+		t.Fatalf("authorization URL lacks PKCE or nonce: %s", authURL)
+	}
+
+	callback, err := provider.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	if callback.Query().Get("state") != state {
+		t.Fatalf("Expected state %q, got %q", state, callback.Query().Get("state"))
+	}
+	return callback.Query().Get("code")
+}
+
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	client, provider := newTestClient(t, oidctest.User{
+		Subject:       "user-123",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		AMR:           []string{"pwd", "mfa"},
+	})
+
+	verifier := "a-verifier-that-is-long-enough-for-pkce-0123456789"
+	code := authorize(t, client, provider, "state-1", "nonce-1", verifier)
+
+	identity, err := client.Exchange(context.Background(), code, "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	if identity.Issuer != provider.Issuer() || identity.Subject != "user-123" {
+		t.Errorf("Unexpected identity %s/%s", identity.Issuer, identity.Subject)
+	}
+	if identity.Email != "jane@example.com" || !identity.EmailVerified || identity.Name != "Jane Doe" {
+		t.Errorf("Unexpected profile %+v", identity)
+	}
+	if len(identity.AMR) != 2 || identity.AMR[1] != "mfa" {
+		t.Errorf("Expected amr [pwd mfa], got %v", identity.AMR)
+	}
+
+	// Codes can only be redeemed once
+	if _, err := client.Exchange(context.Background(), code, "nonce-1", verifier); err == nil {
+		t.Error("Expected a reused code to be rejected")
+	}
+}
+
+func TestClient_RejectsWrongVerifier(t *testing.T) {
+	client, provider := newTestClient(t, oidctest.User{Subject: "user-123"})
+
+	code := authorize(t, client, provider, "state-1", "nonce-1", "a-verifier-that-is-long-enough-for-pkce-0123456789")
+
+	if _, err := client.Exchange(context.Background(), code, "nonce-1", "another-verifier-that-is-long-enough-for-pkce-01"); err == nil {
+		t.Error("Expected an exchange with the wrong PKCE verifier to fail")
+	}
+}
+
+func TestClient_RejectsNonceMismatch(t *testing.T) {
+	client, provider := newTestClient(t, oidctest.User{Subject: "user-123"})
+
+	verifier := "a-verifier-that-is-long-enough-for-pkce-0123456789"
+	code := authorize(t, client, provider, "state-1", "nonce-1", verifier)
+
+	_, err := client.Exchange(context.Background(), code, "nonce-2", verifier)
+	if !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("Expected ErrNonceMismatch, got %v", err)
+	}
+}
+
+func TestClient_DiscoveryFailure(t *testing.T) {
+	client := New(&config.OIDCConfig{Issuer: "http://127.0.0.1:1", ClientID: "test-client"})
+
+	if _, err := client.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Error("Expected discovery of an unreachable provider to fail")
+	}
+}
@@ -0,0 +1,173 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2 keeps the tests quick; production parameters come from the config
+func fastArgon2(cfg config.PasswordConfig) *config.PasswordConfig {
+	cfg.Argon2Memory = 1024
+	cfg.Argon2Iterations = 1
+	cfg.Argon2Parallelism = 1
+	return &cfg
+}
+
+func TestPolicyValidate(t *testing.T) {
+	denylist := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(denylist, []byte("# comment\n\nPassword123\nletmein99\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := NewPolicy(&config.PasswordConfig{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		DenylistFile:  denylist,
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     []string
+	}{
+		{"valid", "Correct-Horse-9", "john@example.com", nil},
+		{"too short", "Ab1!", "", []string{"must be at least 10 characters long"}},
+		{"missing classes", "abcdefghijkl", "", []string{"must contain an uppercase letter", "must contain a digit", "must contain a symbol"}},
+		{"denied", "passWORD123", "", []string{"must contain a symbol", "is too common"}},
+		{"email", "John@Example.com1", "john@example.com1", []string{"must not be the email address"}},
+		{"email local part", "Jo.Hn-1234!", "jo.hn-1234!@example.com", []string{"must not be the email address"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate("new_password", tt.password, tt.email)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate returned %v, want nil", err)
+				}
+				return
+			}
+
+			if !apperrors.IsValidationError(err) {
+				t.Fatalf("Validate returned %v, want a validation error", err)
+			}
+			fields := apperrors.GetFieldErrors(err)
+			var got []string
+			for _, f := range fields {
+				if f.Field != "new_password" {
+					t.Errorf("field = %q, want new_password", f.Field)
+				}
+				got = append(got, f.Message)
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicyMissingDenylist(t *testing.T) {
+	if _, err := NewPolicy(&config.PasswordConfig{DenylistFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Fatal("NewPolicy should fail when the deny-list file does not exist")
+	}
+}
+
+func TestHasherRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			hasher, err := NewHasher(fastArgon2(config.PasswordConfig{Algorithm: algorithm, BcryptCost: bcrypt.MinCost}))
+			if err != nil {
+				t.Fatalf("NewHasher: %v", err)
+			}
+
+			hash, err := hasher.Hash("s3cret-password")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+
+			if ok, err := hasher.Verify("s3cret-password", hash); err != nil || !ok {
+				t.Errorf("Verify(correct) = %v, %v; want true, nil", ok, err)
+			}
+			if ok, err := hasher.Verify("wrong-password", hash); err != nil || ok {
+				t.Errorf("Verify(wrong) = %v, %v; want false, nil", ok, err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Error("NeedsRehash should be false for a hash made with the current settings")
+			}
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	oldBcrypt, err := bcrypt.GenerateFromPassword([]byte("s3cret-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2Hasher, err := NewHasher(fastArgon2(config.PasswordConfig{Algorithm: AlgorithmArgon2id}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHasher, err := NewHasher(&config.PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Old hashes keep working while they are waiting to be upgraded
+	if ok, err := argon2Hasher.Verify("s3cret-password", string(oldBcrypt)); err != nil || !ok {
+		t.Errorf("Verify(bcrypt hash) = %v, %v; want true, nil", ok, err)
+	}
+	if !argon2Hasher.NeedsRehash(string(oldBcrypt)) {
+		t.Error("bcrypt hash should be upgraded to argon2id")
+	}
+	if !bcryptHasher.NeedsRehash(string(oldBcrypt)) {
+		t.Error("bcrypt hash with an outdated cost should be upgraded")
+	}
+
+	argon2Hash, err := argon2Hasher.Hash("s3cret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stronger, err := NewHasher(&config.PasswordConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 2048, Argon2Iterations: 1, Argon2Parallelism: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stronger.NeedsRehash(argon2Hash) {
+		t.Error("argon2id hash with outdated parameters should be upgraded")
+	}
+	if !bcryptHasher.NeedsRehash(argon2Hash) {
+		t.Error("argon2id hash should be replaced when bcrypt is configured")
+	}
+}
+
+func TestHasherRejectsUnknownHash(t *testing.T) {
+	hasher, err := NewHasher(fastArgon2(config.PasswordConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range []string{"", "plaintext", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"} {
+		if ok, err := hasher.Verify("plaintext", hash); ok || err == nil {
+			t.Errorf("Verify(%q) = %v, %v; want false and an error", hash, ok, err)
+		}
+	}
+}
+
+func TestNewHasherRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewHasher(&config.PasswordConfig{Algorithm: "md5"}); err == nil {
+		t.Fatal("NewHasher should reject unsupported algorithms")
+	}
+}
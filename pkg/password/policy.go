@@ -0,0 +1,130 @@
+// Package password implements the password policy and password hashing.
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/IndigoCloud6/go-web-template/internal/config"
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+// defaultMinLength is used when the config does not set a minimum length
+const defaultMinLength = 8
+
+// Policy decides which passwords users may choose
+type Policy struct {
+	minLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	denylist      map[string]struct{}
+}
+
+// NewPolicy creates the policy described by the config, loading the deny-list
+// file if one is configured
+func NewPolicy(cfg *config.PasswordConfig) (*Policy, error) {
+	p := &Policy{
+		minLength:     cfg.MinLength,
+		requireUpper:  cfg.RequireUpper,
+		requireLower:  cfg.RequireLower,
+		requireDigit:  cfg.RequireDigit,
+		requireSymbol: cfg.RequireSymbol,
+		denylist:      make(map[string]struct{}),
+	}
+	if p.minLength <= 0 {
+		p.minLength = defaultMinLength
+	}
+
+	if cfg.DenylistFile != "" {
+		if err := p.loadDenylist(cfg.DenylistFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// loadDenylist reads one password per line. Empty lines and lines starting with # are skipped.
+func (p *Policy) loadDenylist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open password deny-list: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.denylist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read password deny-list: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the password of the account with the given email. It returns
+// a validation error with one entry per violated rule for the named request field.
+func (p *Policy) Validate(field, password, email string) error {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.minLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.requireUpper && !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.requireLower && !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if _, denied := p.denylist[strings.ToLower(password)]; denied {
+		violations = append(violations, "is too common")
+	}
+
+	if email != "" {
+		localPart, _, _ := strings.Cut(email, "@")
+		if strings.EqualFold(password, email) || strings.EqualFold(password, localPart) {
+			violations = append(violations, "must not be the email address")
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fields := make([]apperrors.FieldError, len(violations))
+	for i, v := range violations {
+		fields[i] = apperrors.FieldError{Field: field, Message: v}
+	}
+	return apperrors.NewFieldValidationError("password does not meet the requirements", fields...)
+}
@@ -0,0 +1,242 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+// Page describes the rows of a list page: either the rows after skipping
+// Offset rows, or the rows next to the boundary row of a Keyset
+type Page struct {
+	Sorts  []Sort // Stable sort order of the list
+	Offset int
+	Size   int
+	Keyset *Keyset
+}
+
+// Keyset positions a page next to a boundary row, identified by the values
+// of the page's sort fields. Unlike an offset it does not shift when rows
+// are inserted or deleted, and databases can seek to it through an index.
+type Keyset struct {
+	Values []any
+	Before bool // The page ends before the boundary row instead of starting after it
+}
+
+// Limit returns the number of rows to fetch, one more than the page size to
+// tell whether more rows follow
+func (p Page) Limit() int {
+	return p.Size + 1
+}
+
+// OrderBy returns the order to fetch the rows in. Pages before a keyset are
+// fetched in reverse order, walking away from the boundary row.
+func (p Page) OrderBy() []Sort {
+	if p.Keyset == nil || !p.Keyset.Before {
+		return p.Sorts
+	}
+	reversed := make([]Sort, len(p.Sorts))
+	for i, s := range p.Sorts {
+		reversed[i] = Sort{Field: s.Field, Desc: !s.Desc}
+	}
+	return reversed
+}
+
+// PageInfo describes where a page is in the list
+type PageInfo struct {
+	Total      *int64 // Number of matching rows, nil when they were not counted
+	NextCursor string // Empty on the last page
+	PrevCursor string // Empty on the first page
+}
+
+// Sortable is implemented by models that can be listed with cursors
+type Sortable interface {
+	// SortValue returns the value of one of the fields the model can be sorted by
+	SortValue(field string) any
+}
+
+// CursorCodec encodes keysets as opaque cursors and signs them, so clients
+// cannot craft cursors pointing at arbitrary sort values
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec creates a codec signing cursors with an HMAC of the secret
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{key: []byte(secret)}
+}
+
+// cursor is the signed content of a cursor. It records the sort order it was
+// created for, so it cannot be replayed with a different one.
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []cursorValue `json:"v"`
+	Before bool          `json:"b,omitempty"`
+}
+
+// cursorValue keeps the type of a sort value through JSON
+type cursorValue struct {
+	Kind  string `json:"k"`
+	Value string `json:"v"`
+}
+
+// NewPage returns the page to fetch: the page after or before the cursor
+// when one is given, and the numbered page otherwise. page is ignored when
+// there is a cursor.
+func (c *CursorCodec) NewPage(token string, sorts []Sort, page, size int) (Page, error) {
+	p := Page{Sorts: Stable(sorts), Size: size}
+	if token == "" {
+		p.Offset = (page - 1) * size
+		return p, nil
+	}
+
+	cur, err := c.decode(token)
+	if err != nil {
+		return Page{}, apperrors.NewValidationErrorWithCause("invalid cursor", err)
+	}
+	if cur.Sort != FormatSort(p.Sorts) || len(cur.Values) != len(p.Sorts) {
+		return Page{}, apperrors.NewValidationError("cursor was created for a different sort order")
+	}
+
+	values := make([]any, len(cur.Values))
+	for i, v := range cur.Values {
+		if values[i], err = v.decode(); err != nil {
+			return Page{}, apperrors.NewValidationErrorWithCause("invalid cursor", err)
+		}
+	}
+	p.Keyset = &Keyset{Values: values, Before: cur.Before}
+	return p, nil
+}
+
+// BuildPage trims the extra row fetched for page, restores the sort order of
+// pages fetched before a keyset and returns the cursors of the neighbouring
+// pages
+func BuildPage[T Sortable](c *CursorCodec, page Page, rows []T) ([]T, *PageInfo, error) {
+	more := len(rows) > page.Size
+	if more {
+		rows = rows[:page.Size]
+	}
+
+	hasNext, hasPrev := more, page.Offset > 0
+	if page.Keyset != nil {
+		hasNext, hasPrev = true, true
+		if page.Keyset.Before {
+			slices.Reverse(rows)
+			hasPrev = more
+		} else {
+			hasNext = more
+		}
+	}
+
+	info := &PageInfo{}
+	if len(rows) == 0 {
+		return rows, info, nil
+	}
+
+	var err error
+	if hasNext {
+		if info.NextCursor, err = c.encode(page.Sorts, rows[len(rows)-1], false); err != nil {
+			return nil, nil, err
+		}
+	}
+	if hasPrev {
+		if info.PrevCursor, err = c.encode(page.Sorts, rows[0], true); err != nil {
+			return nil, nil, err
+		}
+	}
+	return rows, info, nil
+}
+
+// encode returns the cursor of the page after, or before, the row
+func (c *CursorCodec) encode(sorts []Sort, row Sortable, before bool) (string, error) {
+	cur := cursor{Sort: FormatSort(sorts), Before: before}
+	for _, s := range sorts {
+		v, err := encodeValue(row.SortValue(s.Field))
+		if err != nil {
+			return "", fmt.Errorf("sort field %s: %w", s.Field, err)
+		}
+		cur.Values = append(cur.Values, v)
+	}
+
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+func (c *CursorCodec) decode(token string) (*cursor, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(sig, c.sign(payload)) {
+		return nil, fmt.Errorf("cursor signature mismatch")
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func encodeValue(v any) (cursorValue, error) {
+	switch v := v.(type) {
+	case string:
+		return cursorValue{Kind: "s", Value: v}, nil
+	case int:
+		return cursorValue{Kind: "i", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return cursorValue{Kind: "i", Value: strconv.FormatInt(v, 10)}, nil
+	case uint:
+		return cursorValue{Kind: "u", Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return cursorValue{Kind: "u", Value: strconv.FormatUint(v, 10)}, nil
+	case float64:
+		return cursorValue{Kind: "f", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case time.Time:
+		return cursorValue{Kind: "t", Value: v.UTC().Format(time.RFC3339Nano)}, nil
+	default:
+		return cursorValue{}, fmt.Errorf("unsupported sort value type %T", v)
+	}
+}
+
+func (v cursorValue) decode() (any, error) {
+	switch v.Kind {
+	case "s":
+		return v.Value, nil
+	case "i":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "u":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(v.Value, 64)
+	case "t":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	default:
+		return nil, fmt.Errorf("unknown sort value kind %q", v.Kind)
+	}
+}
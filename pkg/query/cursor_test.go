@@ -0,0 +1,144 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+type testRow struct {
+	ID        uint
+	Name      string
+	Price     float64
+	CreatedAt time.Time
+}
+
+func (r testRow) SortValue(field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "name":
+		return r.Name
+	case "price":
+		return r.Price
+	case "created_at":
+		return r.CreatedAt
+	}
+	return nil
+}
+
+func testRows(ids ...uint) []testRow {
+	rows := make([]testRow, len(ids))
+	for i, id := range ids {
+		rows[i] = testRow{ID: id, Name: "row", Price: 9.99, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)}
+	}
+	return rows
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	codec := NewCursorCodec("test-secret")
+	sorts := []Sort{{Field: "price", Desc: true}, {Field: "created_at"}}
+
+	first, err := codec.NewPage("", sorts, 1, 2)
+	if err != nil {
+		t.Fatalf("NewPage failed: %v", err)
+	}
+	if first.Keyset != nil || first.Offset != 0 || first.Limit() != 3 {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	rows, info, err := BuildPage(codec, first, testRows(1, 2, 3))
+	if err != nil {
+		t.Fatalf("BuildPage failed: %v", err)
+	}
+	if len(rows) != 2 || info.NextCursor == "" || info.PrevCursor != "" {
+		t.Fatalf("unexpected first page result: %d rows, %+v", len(rows), info)
+	}
+
+	next, err := codec.NewPage(info.NextCursor, sorts, 1, 2)
+	if err != nil {
+		t.Fatalf("NewPage with cursor failed: %v", err)
+	}
+	want := &Keyset{Values: []any{9.99, time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC), uint64(2)}}
+	if !reflect.DeepEqual(next.Keyset, want) {
+		t.Errorf("Keyset = %+v, want %+v", next.Keyset, want)
+	}
+	if got := FormatSort(next.OrderBy()); got != "-price,created_at,id" {
+		t.Errorf("OrderBy = %q", got)
+	}
+}
+
+func TestCursorPrevPage(t *testing.T) {
+	codec := NewCursorCodec("test-secret")
+	sorts := []Sort{{Field: "name"}}
+
+	page, err := codec.NewPage("", sorts, 2, 2)
+	if err != nil {
+		t.Fatalf("NewPage failed: %v", err)
+	}
+	_, info, err := BuildPage(codec, page, testRows(3, 4))
+	if err != nil {
+		t.Fatalf("BuildPage failed: %v", err)
+	}
+	if info.PrevCursor == "" || info.NextCursor != "" {
+		t.Fatalf("unexpected page info: %+v", info)
+	}
+
+	prev, err := codec.NewPage(info.PrevCursor, sorts, 1, 2)
+	if err != nil {
+		t.Fatalf("NewPage with cursor failed: %v", err)
+	}
+	if prev.Keyset == nil || !prev.Keyset.Before {
+		t.Fatalf("expected a page before the cursor: %+v", prev.Keyset)
+	}
+	if got := FormatSort(prev.OrderBy()); got != "-name,-id" {
+		t.Errorf("OrderBy = %q", got)
+	}
+
+	// Rows arrive in reverse order, walking away from the boundary
+	rows, info, err := BuildPage(codec, prev, testRows(2, 1))
+	if err != nil {
+		t.Fatalf("BuildPage failed: %v", err)
+	}
+	if rows[0].ID != 1 || rows[1].ID != 2 {
+		t.Errorf("rows not restored to list order: %+v", rows)
+	}
+	if info.NextCursor == "" || info.PrevCursor != "" {
+		t.Errorf("unexpected page info: %+v", info)
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	codec := NewCursorCodec("test-secret")
+	sorts := []Sort{{Field: "name"}}
+
+	page, _ := codec.NewPage("", sorts, 1, 1)
+	_, info, err := BuildPage(codec, page, testRows(1, 2))
+	if err != nil {
+		t.Fatalf("BuildPage failed: %v", err)
+	}
+	payload, sig, _ := strings.Cut(info.NextCursor, ".")
+
+	tests := []struct {
+		name   string
+		codec  *CursorCodec
+		cursor string
+		sorts  []Sort
+	}{
+		{"garbage", codec, "not-a-cursor", sorts},
+		{"tampered payload", codec, payload + "x." + sig, sorts},
+		{"other secret", NewCursorCodec("other-secret"), info.NextCursor, sorts},
+		{"other sort", codec, info.NextCursor, []Sort{{Field: "name", Desc: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.codec.NewPage(tt.cursor, tt.sorts, 1, 1); !apperrors.IsValidationError(err) {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package query parses the sort parameter of list endpoints, builds the
+// patterns used by their text filters and implements keyset pagination with
+// signed cursors.
+package query
+
+import (
+	"slices"
+	"strings"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+// LikeEscape is the escape character of the patterns returned by Contains.
+// Queries must declare it, e.g. "name LIKE ? ESCAPE '!'", since databases
+// disagree on the default.
+const LikeEscape = "!"
+
+// Sort orders a list by one field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma separated list of fields, each optionally
+// prefixed with - for descending order, e.g. "-price,name". Fields must be
+// in allowed and may appear only once. An empty string returns no sorts.
+func ParseSort(raw string, allowed []string) ([]Sort, error) {
+	var sorts []Sort
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		sort := Sort{Field: part}
+		if strings.HasPrefix(part, "-") {
+			sort = Sort{Field: part[1:], Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			sort.Field = part[1:]
+		}
+
+		if !slices.Contains(allowed, sort.Field) {
+			return nil, apperrors.NewValidationError("cannot sort by " + sort.Field + ", allowed fields: " + strings.Join(allowed, ", "))
+		}
+		if seen[sort.Field] {
+			return nil, apperrors.NewValidationError("duplicate sort field " + sort.Field)
+		}
+		seen[sort.Field] = true
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// Stable returns the sorts followed by id ascending, unless they already
+// order by id, so rows with equal sort values keep a stable order
+func Stable(sorts []Sort) []Sort {
+	for _, s := range sorts {
+		if s.Field == "id" {
+			return sorts
+		}
+	}
+	return append(slices.Clip(sorts), Sort{Field: "id"})
+}
+
+// FormatSort is the inverse of ParseSort
+func FormatSort(sorts []Sort) string {
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		} else {
+			parts[i] = s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Contains returns a LIKE pattern matching values that contain s, with the
+// wildcards in s escaped with LikeEscape
+func Contains(s string) string {
+	r := strings.NewReplacer(LikeEscape, LikeEscape+LikeEscape, "%", LikeEscape+"%", "_", LikeEscape+"_")
+	return "%" + r.Replace(s) + "%"
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "price", "created_at"}
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    []Sort
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"single ascending", "name", []Sort{{Field: "name"}}, false},
+		{"multiple fields", "-price,name", []Sort{{Field: "price", Desc: true}, {Field: "name"}}, false},
+		{"explicit ascending and spaces", " +created_at , -name ", []Sort{{Field: "created_at"}, {Field: "name", Desc: true}}, false},
+		{"empty parts are skipped", "name,,", []Sort{{Field: "name"}}, false},
+		{"unknown field", "password", nil, true},
+		{"sql injection", "name;drop table users", nil, true},
+		{"duplicate field", "name,-name", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.raw, allowed)
+			if tt.wantErr {
+				if !apperrors.IsValidationError(err) {
+					t.Errorf("Expected validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"phone", "%phone%"},
+		{"50%", "%50!%%"},
+		{"a_b", "%a!_b%"},
+		{"wow!", "%wow!!%"},
+	}
+
+	for _, tt := range tests {
+		if got := Contains(tt.in); got != tt.want {
+			t.Errorf("Contains(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package response
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
 	apperrors "github.com/IndigoCloud6/go-web-template/pkg/errors"
 	"github.com/gin-gonic/gin"
@@ -40,9 +42,17 @@ func Error(c *gin.Context, code int, message string) {
 func ErrorFromAppError(c *gin.Context, err error) {
 	httpStatus := apperrors.GetHTTPStatusCode(err)
 	message := apperrors.GetErrorMessage(err)
+	if retryAfter := apperrors.GetRetryAfter(err); retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	var data interface{}
+	if fields := apperrors.GetFieldErrors(err); len(fields) > 0 {
+		data = map[string]interface{}{"errors": fields}
+	}
 	c.JSON(httpStatus, Response{
 		Code:    httpStatus,
 		Message: message,
+		Data:    data,
 	})
 }
 